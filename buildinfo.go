@@ -0,0 +1,50 @@
+package errbox
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Names of fields set on every new StackErr when the StampBuildInfo option is enabled.
+const (
+	FieldBuildVersion  = "build.version"  // version of the main module, like v1.2.3 or (devel)
+	FieldBuildRevision = "build.revision" // VCS revision the binary was built from
+	FieldBuildTime     = "build.time"     // time of the VCS revision, in RFC3339 format
+)
+
+// buildFields are read from the binary only once, see buildInfoFields.
+var (
+	buildOnce   sync.Once
+	buildFields map[string]string
+)
+
+// buildInfoFields returns fields describing the build of the running binary.
+// Fields which are not known (typically, the binary was built without VCS information) are missing.
+func buildInfoFields() map[string]string {
+	buildOnce.Do(func() {
+		buildFields = make(map[string]string)
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Version != "" {
+			buildFields[FieldBuildVersion] = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				buildFields[FieldBuildRevision] = s.Value
+			case "vcs.time":
+				buildFields[FieldBuildTime] = s.Value
+			}
+		}
+	})
+	return buildFields
+}
+
+// stampBuildInfo adds fields describing the build of the running binary to the error.
+func (b *StackErr) stampBuildInfo() {
+	for k, v := range buildInfoFields() {
+		b.SetField(k, v)
+	}
+}
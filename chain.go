@@ -0,0 +1,275 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Chain is a chain of steps, which run one after another up to the point when one of them fails, or when
+// the context of the chain is done. It is a counterpart of Run and Then for steps which accept a context:
+//
+//	err := errbox.RunCtx(ctx).
+//		Then(download).
+//		Then(unpack).
+//		Then(install).
+//		First()
+type Chain struct {
+	ctx       context.Context
+	box       *Box
+	step      int  // number of steps which ran so far
+	all       bool // run all steps, see All
+	cancelled bool // the chain was stopped, because the context is done
+}
+
+// RunCtx returns a new chain with the ctx, which is passed to every step. See Chain.
+func RunCtx(ctx context.Context) *Chain {
+	return &Chain{ctx: ctx, box: NewBox()}
+}
+
+// All switches the chain to the collect-all mode: every following step runs, even if a previous one failed,
+// and all failures are gathered in the chain (see Err). It is meant for validation pipelines which must report
+// everything at once:
+//
+//	err := errbox.RunCtx(ctx).All().
+//		Then(checkName).
+//		Then(checkEmail).
+//		Then(checkAddress).
+//		Err()
+//
+// The chain still stops when its context is done.
+func (c *Chain) All() *Chain {
+	c.all = true
+	return c
+}
+
+// Then runs the fn with the context of the chain, if and only if no step failed so far (unless the chain
+// is in the collect-all mode, see All). If the fn returns an error, it is appended to the chain, and the remaining
+// steps are skipped.
+//
+// If the context is done before the fn runs, the fn is skipped, and the cause of the cancellation
+// (see context.Cause) is appended instead, annotated by the number of the step which was the next one to run.
+// If the fn fails while the context is done, its error is annotated by the number of the step, which was in flight
+// when the cancellation hit. Steps are counted from 1.
+func (c *Chain) Then(fn func(ctx context.Context) error) *Chain {
+	c.box.pushErr(c.then(func() string { return fmt.Sprintf("step %d", c.step) }, fn))
+	return c
+}
+
+// ThenNamed works like Then, but the error of the fn is annotated with the name of the step and the place where
+// ThenNamed was called, so that bodies of steps do not need to be wrapped by Annotate:
+//
+//	err := errbox.RunCtx(ctx).
+//		ThenNamed("load config", loadConfig).
+//		ThenNamed("connect", connect).
+//		First()
+func (c *Chain) ThenNamed(name string, fn func(ctx context.Context) error) *Chain {
+	err := c.then(func() string { return fmt.Sprintf("step %q", name) }, fn)
+	if err != nil {
+		err = annotateErr(3, err, "step %q failed", name)
+	}
+	c.box.pushErr(err)
+	return c
+}
+
+// ThenParallel works like Then, but it runs all the fns concurrently (see Box.Go), waits for them, and appends
+// all their failures to the chain, so that fan-out sections do not break the chain:
+//
+//	err := errbox.RunCtx(ctx).
+//		Then(prepare).
+//		ThenParallel(resizeImages, renderPages, buildIndex).
+//		Then(publish).
+//		First()
+//
+// The remaining steps are skipped if any of the fns failed. The fns are counted as one step.
+func (c *Chain) ThenParallel(fns ...func() error) *Chain {
+	c.box.pushErr(c.then(func() string { return fmt.Sprintf("step %d", c.step) }, func(ctx context.Context) error {
+		var box Box
+		for _, fn := range fns {
+			box.Go(fn)
+		}
+		return box.Wait()
+	}))
+	return c
+}
+
+// ThenWithTimeout works like Then, but the fn gets a context which is done after the timeout d. If the fn
+// does not return by then, the chain abandons it (the fn keeps running in its goroutine, until it returns),
+// and appends context.DeadlineExceeded annotated with the number of the step and the place where
+// ThenWithTimeout was called:
+//
+//	err := errbox.RunCtx(ctx).
+//		ThenWithTimeout(5*time.Second, callPartnerAPI).
+//		First()
+func (c *Chain) ThenWithTimeout(d time.Duration, fn func(ctx context.Context) error) *Chain {
+	var timedOut bool
+	err := c.then(func() string { return fmt.Sprintf("step %d", c.step) }, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			var err error
+			defer func() { done <- err }()
+			defer Recover(&err)
+			err = fn(ctx)
+		}()
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		timedOut = errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) && c.ctx.Err() == nil
+		return err
+	})
+	if timedOut {
+		err = annotateErr(3, err, "step %d timed out after %v", c.step, d)
+	}
+	c.box.pushErr(err)
+	return c
+}
+
+// RetryPolicy controls retries of Chain.ThenRetry.
+type RetryPolicy struct {
+	If     func(err error) bool // retry only errors for which it returns true, all errors if nil
+	Jitter float64              // randomize every delay by up to this fraction of it, like 0.2
+}
+
+// RetryOption configures retries of Chain.ThenRetry.
+type RetryOption func(policy *RetryPolicy)
+
+// RetryIf retries only errors for which the fn returns true, for example:
+//
+//	chain.ThenRetry(5, time.Second, call, errbox.RetryIf(errbox.IsRetryable))
+func RetryIf(fn func(err error) bool) RetryOption {
+	return func(policy *RetryPolicy) { policy.If = fn }
+}
+
+// RetryJitter randomizes every delay by up to the fraction of it (0.2 adds up to 20 %), so that clients
+// which failed at the same time do not retry at the same time.
+func RetryJitter(fraction float64) RetryOption {
+	return func(policy *RetryPolicy) { policy.Jitter = fraction }
+}
+
+// ThenRetry works like Then, but the fn is called up to n times, until it succeeds. The first retry waits
+// for the backoff, and every next one waits twice as long as the previous one. The chain stops waiting when
+// its context is done.
+//
+// If all attempts fail (or if the error is not to be retried, see RetryIf), the last error is annotated with
+// the number of attempts, the total time spent, and the place where ThenRetry was called:
+//
+//	err := errbox.RunCtx(ctx).
+//		ThenRetry(3, 100*time.Millisecond, fetch, errbox.RetryJitter(0.2)).
+//		First()
+func (c *Chain) ThenRetry(n int, backoff time.Duration, fn func(ctx context.Context) error, opts ...RetryOption) *Chain {
+	var policy RetryPolicy
+	for _, opt := range opts {
+		opt(&policy)
+	}
+	attempts, start := 0, time.Now()
+	err := c.then(func() string { return fmt.Sprintf("step %d", c.step) }, func(ctx context.Context) error {
+		delay := backoff
+		for {
+			attempts++
+			err := fn(ctx)
+			if err == nil || attempts >= n || (policy.If != nil && !policy.If(err)) {
+				return err
+			}
+			wait := delay
+			if policy.Jitter > 0 {
+				wait += time.Duration(rand.Float64() * policy.Jitter * float64(delay))
+			}
+			delay *= 2
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	})
+	if err != nil && attempts > 0 {
+		err = annotateErr(3, err, "failed after %d attempts in %v", attempts, time.Since(start).Round(time.Millisecond))
+	}
+	c.box.pushErr(err)
+	return c
+}
+
+// then implements Then, the name returns the name of the current step. It returns the error which should be
+// appended to the chain, if any.
+func (c *Chain) then(name func() string, fn func(ctx context.Context) error) error {
+	if c.cancelled || (!c.all && !c.box.IsEmpty()) {
+		return nil
+	}
+	c.step++
+	if c.ctx.Err() != nil {
+		c.cancelled = true
+		return Annotate(context.Cause(c.ctx), "chain cancelled before %s", name())
+	}
+	err := fn(c.ctx)
+	if err != nil && c.ctx.Err() != nil {
+		err = Annotate(err, "%s was in flight when the chain was cancelled", name())
+	}
+	return err
+}
+
+// Catch recovers the chain from failures which are the target (think errors.Is): the error is replaced
+// by the error returned by the handler. If the handler returns nil, the failure is forgotten, and the chain
+// continues with the next step, like after a try/catch block:
+//
+//	err := errbox.RunCtx(ctx).
+//		Then(loadCache).
+//		Catch(fs.ErrNotExist, func(err error) error { return nil }). // no cache yet, that's fine
+//		Then(process).
+//		First()
+//
+// If the chain did not fail, or it failed with another error, the handler is not called.
+func (c *Chain) Catch(target error, handler func(err error) error) *Chain {
+	if c.box.IsEmpty() {
+		return c
+	}
+	c.box = c.box.Map(func(err error) error {
+		if errors.Is(err, target) {
+			return handler(err)
+		}
+		return err
+	})
+	return c
+}
+
+// Finally runs the fn whether the chain failed or not (even if the context of the chain is done),
+// and appends its error to the chain, like a finally block. Because steps run as soon as they are added,
+// Finally should be the last one:
+//
+//	err := errbox.RunCtx(ctx).
+//		Then(lock).
+//		Then(update).
+//		Finally(unlock).
+//		First()
+//
+// Steps which follow Finally are skipped, if the chain failed.
+func (c *Chain) Finally(fn func() error) *Chain {
+	c.box.pushErr(fn())
+	return c
+}
+
+// First returns the first error that was encountered, or nil if no step failed.
+func (c *Chain) First() error {
+	return c.box.First()
+}
+
+// Err returns a Box with all errors that were encountered, or nil if no step failed.
+func (c *Chain) Err() error {
+	if c.box.IsEmpty() {
+		return nil
+	}
+	return c.box
+}
+
+// Last returns the last error that was encountered, or nil if no step failed.
+func (c *Chain) Last() error {
+	return c.box.Last()
+}
@@ -0,0 +1,193 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	var steps []int
+	step := func(n int, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			steps = append(steps, n)
+			return err
+		}
+	}
+	err := RunCtx(context.Background()).Then(step(1, nil)).Then(step(2, errors.New("failed"))).Then(step(3, nil)).First()
+	if len(steps) != 2 || Cause(err).Error() != "failed" {
+		t.Errorf("expected the chain to stop at step 2, got %v, %v", steps, err)
+	}
+	if err := RunCtx(context.Background()).Then(step(1, nil)).First(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestChainCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var called bool
+	err := RunCtx(ctx).
+		Then(func(ctx context.Context) error { return nil }).
+		Then(func(ctx context.Context) error { cancel(); return ctx.Err() }).
+		Then(func(ctx context.Context) error { called = true; return nil }).
+		First()
+	if called {
+		t.Errorf("expected the step after the cancellation to be skipped")
+	}
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "step 2 was in flight when the chain was cancelled") {
+		t.Errorf("expected the in-flight step to be recorded, got:\n%v", err)
+	}
+
+	err = RunCtx(ctx).Then(func(ctx context.Context) error { return nil }).First()
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "chain cancelled before step 1") {
+		t.Errorf("expected the chain to be cancelled, got:\n%v", err)
+	}
+}
+
+func TestChainCatchFinally(t *testing.T) {
+	errMissing := errors.New("missing")
+	var recovered error
+	var steps int
+	err := RunCtx(context.Background()).
+		Then(func(ctx context.Context) error { return Annotate(errMissing, "loading cache") }).
+		Catch(context.Canceled, func(err error) error { t.Errorf("unexpected catch of %v", err); return nil }).
+		Catch(errMissing, func(err error) error { recovered = err; return nil }).
+		Then(func(ctx context.Context) error { steps++; return nil }).
+		Finally(func() error { steps++; return nil }).
+		First()
+	if err != nil || steps != 2 || !errors.Is(recovered, errMissing) {
+		t.Errorf("expected the chain to recover, got %v, %v steps, recovered %v", err, steps, recovered)
+	}
+
+	c := RunCtx(context.Background()).
+		Then(func(ctx context.Context) error { return errMissing }).
+		Catch(errMissing, func(err error) error { return Annotate(err, "still missing") }).
+		Finally(func() error { return errors.New("unlock failed") })
+	if err := c.First(); !strings.Contains(err.Error(), "still missing") {
+		t.Errorf("expected the replaced error, got:\n%v", err)
+	}
+	if err := c.Last(); Cause(err).Error() != "unlock failed" {
+		t.Errorf("expected the error of Finally, got:\n%v", err)
+	}
+}
+
+func TestChainThenNamed(t *testing.T) {
+	err := RunCtx(context.Background()).
+		ThenNamed("load config", func(ctx context.Context) error { return nil }).
+		ThenNamed("connect", func(ctx context.Context) error { return errors.New("refused") }).
+		First()
+	msg := err.Error()
+	if !strings.Contains(msg, `step "connect" failed`) || strings.Contains(msg, "load config") {
+		t.Errorf("expected the failing step to be named, got:\n%s", msg)
+	}
+	if frames := WithStack(err).annotation; frames[len(frames)-1].function != "TestChainThenNamed" {
+		t.Errorf("expected the call site of ThenNamed, got %+v", frames[len(frames)-1])
+	}
+}
+
+func TestChainAll(t *testing.T) {
+	var steps int
+	err := RunCtx(context.Background()).All().
+		Then(func(ctx context.Context) error { steps++; return errors.New("bad name") }).
+		Then(func(ctx context.Context) error { steps++; return nil }).
+		Then(func(ctx context.Context) error { steps++; return errors.New("bad email") }).
+		Err()
+	if steps != 3 || len(Errors(err)) != 2 {
+		t.Errorf("expected all steps to run, and 2 errors, got %v steps:\n%v", steps, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RunCtx(ctx).All().
+		Then(func(ctx context.Context) error { return nil }).
+		Then(func(ctx context.Context) error { return nil }).
+		Err()
+	if errs := Errors(err); len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("expected one cancellation, got:\n%v", err)
+	}
+	if err := RunCtx(context.Background()).All().Err(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestChainThenRetry(t *testing.T) {
+	var attempts int
+	err := RunCtx(context.Background()).
+		ThenRetry(3, time.Millisecond, func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("flaky")
+			}
+			return nil
+		}, RetryJitter(0.5)).
+		First()
+	if err != nil || attempts != 3 {
+		t.Errorf("expected success on the third attempt, got %v attempts, %v", attempts, err)
+	}
+
+	attempts = 0
+	err = RunCtx(context.Background()).
+		ThenRetry(5, time.Millisecond, func(ctx context.Context) error {
+			attempts++
+			if attempts == 2 {
+				return Permanent(errors.New("denied"))
+			}
+			return MarkRetryable(errors.New("flaky"))
+		}, RetryIf(IsRetryable)).
+		First()
+	if attempts != 2 || Cause(err).Error() != "denied" || !strings.Contains(err.Error(), "failed after 2 attempts in") {
+		t.Errorf("expected to give up on the permanent error, got %v attempts:\n%v", attempts, err)
+	}
+
+	attempts = 0
+	err = RunCtx(context.Background()).
+		ThenRetry(2, time.Millisecond, func(ctx context.Context) error { attempts++; return errors.New("down") }).
+		First()
+	if attempts != 2 || !strings.Contains(err.Error(), "failed after 2 attempts in") {
+		t.Errorf("expected 2 attempts, got %v:\n%v", attempts, err)
+	}
+}
+
+func TestChainThenParallel(t *testing.T) {
+	var called bool
+	c := RunCtx(context.Background()).
+		ThenParallel(
+			func() error { return nil },
+			func() error { return errors.New("resize failed") },
+			func() error { panic("render failed") },
+		).
+		Then(func(ctx context.Context) error { called = true; return nil })
+	if called {
+		t.Errorf("expected the step after the failure to be skipped")
+	}
+	if errs := Errors(c.Err()); len(errs) != 2 {
+		t.Errorf("expected 2 errors, got:\n%v", c.Err())
+	}
+	if err := RunCtx(context.Background()).ThenParallel(func() error { return nil }).First(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestChainThenWithTimeout(t *testing.T) {
+	err := RunCtx(context.Background()).
+		Then(func(ctx context.Context) error { return nil }).
+		ThenWithTimeout(10*time.Millisecond, func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}).
+		First()
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "step 2 timed out after 10ms") {
+		t.Errorf("expected the step to time out, got:\n%v", err)
+	}
+
+	err = RunCtx(context.Background()).
+		ThenWithTimeout(time.Second, func(ctx context.Context) error { return errors.New("refused") }).
+		First()
+	if Cause(err).Error() != "refused" || strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected the error of the step, got:\n%v", err)
+	}
+}
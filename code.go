@@ -0,0 +1,197 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WithCode returns the err (as *StackErr) with the error code set, or nil, if the err is nil.
+// The code is a stable, machine readable identifier of the failure (like "E_DISK_FULL"), which survives
+// wrapping and grouping in boxes, see CodeOf:
+//
+//	return errbox.WithCode(err, "ORDER_NOT_FOUND")
+//
+// Call of WithCode on error which is a *Box sets the code on all errors in the box.
+//
+// If the StrictCodes option is set, WithCode panics when the code was not registered by RegisterCode.
+func WithCode(err error, code string) error {
+	if isNil(err) {
+		return nil
+	}
+	if currentOptions().StrictCodes {
+		if verr := ValidateCode(code); verr != nil {
+			panic(verr)
+		}
+	}
+	return setCode(err, code)
+}
+
+// RestoreCode works like WithCode, but it never validates the code, even if the StrictCodes option is set.
+// It is meant for integrations which reconstruct errors received from other processes (see Restore),
+// where codes come from the wire, and they do not need to be registered locally.
+func RestoreCode(err error, code string) error {
+	if isNil(err) {
+		return nil
+	}
+	return setCode(err, code)
+}
+
+// setCode implements WithCode and RestoreCode, without validation of the code.
+func setCode(err error, code string) error {
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			setCode(e, code)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.code = code
+	return be
+}
+
+// CodeOf returns the code set by WithCode on the err, on any error it wraps, or on any error in a box found
+// along the way (the outermost code wins; in a box, the first error which has the code wins).
+// Returns empty string if there is no code.
+func CodeOf(err error) string {
+	code, _ := lookup(err, (*StackErr).ownCode)
+	return code
+}
+
+// ownCode returns the code set directly on the error, and true if it was set.
+func (b *StackErr) ownCode() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.code, b.code != ""
+}
+
+// ErrUnknownCode is returned by ValidateCode if the code was not registered.
+var ErrUnknownCode = errors.New("errbox: unknown error code")
+
+// CodeInfo describes an error code registered by RegisterCode.
+type CodeInfo struct {
+	Code        string // the code itself, like "E1021"
+	Description string // short description of the failure, like "upstream quota exceeded"
+	Kind        Kind   // category of errors with the code, KindUnknown if not set
+	HTTPStatus  int    // HTTP status of errors with the code (see HTTPStatus), zero if not set
+	ExitCode    int    // process exit code of errors with the code (see ExitCode), zero if not set
+}
+
+// CodeOption configures the code registered by RegisterCode.
+type CodeOption func(info *CodeInfo)
+
+// CodeKind sets the kind of the code, it is used for documentation.
+func CodeKind(kind Kind) CodeOption {
+	return func(info *CodeInfo) { info.Kind = kind }
+}
+
+// CodeHTTPStatus sets the HTTP status of errors with the code, see RegisterHTTPStatus.
+func CodeHTTPStatus(status int) CodeOption {
+	return func(info *CodeInfo) { info.HTTPStatus = status }
+}
+
+// CodeExitCode sets the process exit code of errors with the code, see RegisterExitCode.
+func CodeExitCode(exitCode int) CodeOption {
+	return func(info *CodeInfo) { info.ExitCode = exitCode }
+}
+
+var (
+	// codes holds codes registered by RegisterCode, protected by codesMu.
+	codes   = make(map[string]CodeInfo)
+	codesMu sync.RWMutex
+)
+
+// RegisterCode registers the error code with its short description, so that:
+//
+//   - the description is printed out next to the code when the error is printed out,
+//   - codes can be validated (see ValidateCode and the StrictCodes option),
+//   - all codes can be exported to the documentation (see Codes and WriteCodes).
+//
+// It is meant to be called from init functions, but it is safe for concurrent use.
+// Registering the same code again replaces it.
+//
+//	func init() {
+//		errbox.RegisterCode("E1021", "upstream quota exceeded", errbox.CodeHTTPStatus(http.StatusTooManyRequests))
+//	}
+func RegisterCode(code, description string, opts ...CodeOption) {
+	info := CodeInfo{Code: code, Description: description}
+	for _, opt := range opts {
+		opt(&info)
+	}
+	if info.HTTPStatus != 0 {
+		RegisterHTTPStatus(code, info.HTTPStatus)
+	}
+	if info.ExitCode != 0 {
+		RegisterExitCode(code, info.ExitCode)
+	}
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	codes[code] = info
+}
+
+// LookupCode returns the registered code (see RegisterCode), and true if it was found.
+func LookupCode(code string) (CodeInfo, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	info, ok := codes[code]
+	return info, ok
+}
+
+// ValidateCode returns an error wrapping ErrUnknownCode if the code was not registered by RegisterCode.
+func ValidateCode(code string) error {
+	if _, ok := LookupCode(code); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCode, code)
+	}
+	return nil
+}
+
+// Codes returns all registered codes (see RegisterCode), sorted by the code.
+func Codes() []CodeInfo {
+	codesMu.RLock()
+	infos := make([]CodeInfo, 0, len(codes))
+	for _, info := range codes {
+		infos = append(infos, info)
+	}
+	codesMu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// WriteCodes writes all registered codes (see Codes) to the w as a markdown table, which can be included
+// in the documentation.
+func WriteCodes(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("| Code | Description | Kind | HTTP status | Exit code |\n")
+	sb.WriteString("|------|-------------|------|-------------|-----------|\n")
+	for _, info := range Codes() {
+		kind := ""
+		if info.Kind != KindUnknown {
+			kind = info.Kind.String()
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			info.Code, info.Description, kind, optionalInt(info.HTTPStatus), optionalInt(info.ExitCode)))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// optionalInt formats the i, or returns empty string if it is zero.
+func optionalInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return fmt.Sprint(i)
+}
+
+// formatCode returns the code with its description (if it was registered), like "E1021 (upstream quota exceeded)".
+func formatCode(code string) string {
+	if info, ok := LookupCode(code); ok && info.Description != "" {
+		return code + " (" + info.Description + ")"
+	}
+	return code
+}
@@ -0,0 +1,72 @@
+package errbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+
+	err := WithCode(errors.New("no such order"), "ORDER_NOT_FOUND")
+	if s := err.Error(); s != "no such order\n code: ORDER_NOT_FOUND\n" {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+	wrapped := fmt.Errorf("handler: %w", Annotate(err, "loading order"))
+	if c := CodeOf(wrapped); c != "ORDER_NOT_FOUND" {
+		t.Errorf("expected code to survive wrapping, got %q", c)
+	}
+	box := NewBox()
+	box.Group("orders").PushIf(wrapped, "")
+	if c := CodeOf(box); c != "ORDER_NOT_FOUND" {
+		t.Errorf("expected code to survive grouping, got %q", c)
+	}
+	if c := CodeOf(errors.New("plain")); c != "" {
+		t.Errorf("expected no code, got %q", c)
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+	RegisterCode("E1021", "upstream quota exceeded", CodeKind(KindUnavailable), CodeHTTPStatus(http.StatusTooManyRequests))
+
+	err := WithCode(errors.New("quota"), "E1021")
+	if s := err.Error(); s != "quota\n code: E1021 (upstream quota exceeded)\n" {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+	if s := HTTPStatus(err); s != http.StatusTooManyRequests {
+		t.Errorf("expected registered HTTP status, got %d", s)
+	}
+	if err := ValidateCode("E9999"); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("expected unknown code, got %v", err)
+	}
+
+	var sb strings.Builder
+	if err := WriteCodes(&sb); err != nil || !strings.Contains(sb.String(), "| E1021 | upstream quota exceeded | unavailable | 429 |  |\n") {
+		t.Errorf("unexpected table:\n%s", sb.String())
+	}
+
+	opts := CurrentOptions()
+	defer Configure(opts)
+	opts.StrictCodes = true
+	Configure(opts)
+	if CodeOf(RestoreCode(errors.New("remote"), "E102l")) != "E102l" {
+		t.Errorf("expected RestoreCode to accept any code")
+	}
+	data, _ := json.Marshal(RestoreCode(errors.New("remote"), "REMOTE_ONLY"))
+	if decoded, _ := FromJSON(data); CodeOf(decoded) != "REMOTE_ONLY" {
+		t.Errorf("expected FromJSON to accept any code")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on unknown code")
+		}
+	}()
+	WithCode(errors.New("typo"), "E102l")
+}
@@ -0,0 +1,176 @@
+package errbox
+
+import (
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// Options controls how errors are annotated and printed out.
+//
+// Options are stored atomically, and can be safely replaced at runtime by Configure, even while other
+// goroutines annotate or print errors. Errors which were already annotated keep the file names which
+// were valid at the time of the annotation.
+type Options struct {
+	// FilePrefix is searched for in file names recorded in the stack trace, and everything before the prefix
+	// plus the prefix itself is dropped from the file name. See OmitPrefixFromTrace.
+	FilePrefix string
+
+	// Sanitize is applied to every file name recorded in the stack trace (after FilePrefix was removed),
+	// if it is not nil. See SanitizeTraceWith.
+	Sanitize func(file string) string
+
+	// HideStack controls if stack trace is NOT printed out, see ShowStack.
+	HideStack bool
+
+	// StampBuildInfo controls if every new StackErr gets fields with version and VCS revision of the binary,
+	// so that error reports identify the exact build. See FieldBuildVersion and friends.
+	StampBuildInfo bool
+
+	// StrictCodes makes WithCode panic when the code was not registered by RegisterCode. It is meant for tests
+	// and development builds, to catch typos in codes early.
+	StrictCodes bool
+
+	// ServiceName is the name of the service, which is recorded by MarshalJSON (and encoders built on top of it),
+	// so that errors decoded in another service are reconstructed as RemoteErr. Empty name is not recorded.
+	ServiceName string
+
+	// Deterministic makes printed out errors independent of the build and the run: only base names of files
+	// are printed out, line numbers are replaced by "NN", and goroutine IDs and addresses in panic stacks
+	// are dropped. It is meant for tests which compare errors with golden files. See DeterministicOutput.
+	Deterministic bool
+
+	// StackDropRate is the fraction of errors, for which places in code are NOT recorded, like 0.99.
+	// Zero records places for all errors, 1 for none of them. See SampleStacks.
+	StackDropRate float64
+}
+
+// DefaultOptions returns options which are used when Configure was never called. They are the zero Options,
+// so that options which are not set are the defaults.
+func DefaultOptions() Options {
+	return Options{}
+}
+
+// config holds *Options currently in use.
+var config atomic.Value
+
+// configMu serializes read-modify-write of the config (see update), readers do NOT need it.
+var configMu sync.Mutex
+
+func init() {
+	opts := DefaultOptions()
+	config.Store(&opts)
+}
+
+// Configure atomically replaces all options. It is safe to call it at any time, from any goroutine.
+//
+// Options which are not set in the opts are reset to their defaults (see DefaultOptions); to change only some
+// options, and keep the others, start from CurrentOptions:
+//
+//	opts := errbox.CurrentOptions()
+//	opts.FilePrefix = "github.com/acme/"
+//	errbox.Configure(opts)
+func Configure(opts Options) {
+	opts.FilePrefix = filepath.ToSlash(opts.FilePrefix)
+	configMu.Lock()
+	defer configMu.Unlock()
+	config.Store(&opts)
+}
+
+// CurrentOptions returns a copy of the options currently in use.
+func CurrentOptions() Options {
+	return *currentOptions()
+}
+
+// currentOptions returns the options currently in use. The returned value must NOT be modified.
+func currentOptions() *Options {
+	return config.Load().(*Options)
+}
+
+// update atomically modifies the options currently in use by the fn.
+func update(fn func(opts *Options)) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	opts := *currentOptions()
+	fn(&opts)
+	config.Store(&opts)
+}
+
+// OmitPrefixFromTrace will SET the FilePrefix option.
+// Later, when errors are annotated, stack trace is inspected.
+// Filename where the error occured is searched for the prefix, and everything before the prefix plus the prefix itself
+// is dropped from the filename.
+//
+// Why is this useful: suppose you have package called recombobulator, and you do not want to print out the path
+// to the current package in our error. You can achieve this by calling OmitPrefixFromTrace("recombobulator/").
+//
+// It is safe to call this function at any time, see Configure.
+func OmitPrefixFromTrace(pfx string) {
+	pfx = filepath.ToSlash(pfx)
+	update(func(opts *Options) { opts.FilePrefix = pfx })
+}
+
+// SanitizeTraceWith will SET the Sanitize option. The function is called for every file name
+// which is recorded in the stack trace, after the prefix set by OmitPrefixFromTrace was removed,
+// and the returned value is used instead of the original file name.
+//
+// Why is this useful: CI paths, container paths and GOPATH prefixes rarely share a single literal prefix,
+// and a function can normalize all of them. Nil function disables the sanitization.
+//
+// It is safe to call this function at any time, see Configure.
+func SanitizeTraceWith(fn func(file string) string) {
+	update(func(opts *Options) { opts.Sanitize = fn })
+}
+
+// SanitizeTraceRegexp is a shorthand for SanitizeTraceWith, which replaces all matches of the re in the file name by repl.
+// The repl can contain references to submatches, see regexp.Regexp.ReplaceAllString.
+//
+// For example, SanitizeTraceRegexp(regexp.MustCompile(`^.*/pkg/mod/`), "") drops everything up to the module cache.
+func SanitizeTraceRegexp(re *regexp.Regexp, repl string) {
+	SanitizeTraceWith(func(file string) string {
+		return re.ReplaceAllString(file, repl)
+	})
+}
+
+// ShowStack will SET the HideStack option to the opposite of the show. This option controls how errors are printed out.
+//
+// It is safe to call this function at any time, see Configure.
+func ShowStack(show bool) {
+	update(func(opts *Options) { opts.HideStack = !show })
+}
+
+// DeterministicOutput will SET the Deterministic option, see Options.
+//
+// It is safe to call this function at any time, see Configure.
+func DeterministicOutput(on bool) {
+	update(func(opts *Options) { opts.Deterministic = on })
+}
+
+// toggle is a tri-state override of a boolean option, the zero value inherits the option.
+type toggle int8
+
+const (
+	inherit toggle = iota
+	on
+	off
+)
+
+// toggleOf converts the bool to an explicit override.
+func toggleOf(v bool) toggle {
+	if v {
+		return on
+	}
+	return off
+}
+
+// resolve returns def if the toggle inherits the option, otherwise returns the override.
+func (t toggle) resolve(def bool) bool {
+	switch t {
+	case on:
+		return true
+	case off:
+		return false
+	}
+	return def
+}
@@ -0,0 +1,30 @@
+package errbox
+
+import "context"
+
+// boxKey is the context key of the box, see WithBox.
+type boxKey struct{}
+
+// WithBox returns a copy of the ctx which carries a new box, and the box itself. Deeply nested code (typically,
+// request handlers) can then push non-fatal problems (warnings, partial failures) into the box by BoxFrom,
+// without threading the box through all the calls:
+//
+//	ctx, warnings := errbox.WithBox(r.Context())
+//	resp, err := handle(ctx, req)
+//	// ...
+//	if !warnings.IsEmpty() {
+//		log.Println(warnings)
+//	}
+func WithBox(ctx context.Context) (context.Context, *Box) {
+	b := NewBox()
+	return context.WithValue(ctx, boxKey{}, b), b
+}
+
+// BoxFrom returns the box carried by the ctx (see WithBox). If the ctx carries no box, a new detached box is
+// returned, so that callers do not need to check for nil; errors pushed into it are not seen by anyone.
+func BoxFrom(ctx context.Context) *Box {
+	if b, ok := ctx.Value(boxKey{}).(*Box); ok {
+		return b
+	}
+	return NewBox()
+}
@@ -0,0 +1,22 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithBox(t *testing.T) {
+	ctx, warnings := WithBox(context.Background())
+	handle := func(ctx context.Context) {
+		BoxFrom(ctx).PushIf(errors.New("cache is cold"), "loading profile")
+	}
+	handle(context.WithValue(ctx, struct{}{}, "nested"))
+	if warnings.Len() != 1 {
+		t.Errorf("expected one warning, got %d", warnings.Len())
+	}
+
+	if b := BoxFrom(context.Background()); b == nil || !b.IsEmpty() {
+		t.Errorf("expected a new empty box")
+	}
+}
@@ -0,0 +1,35 @@
+package errbox
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// location formats the place in code, like "errbox/stack.go:42", or like "stack.go:NN" if the Deterministic
+// option is set.
+func location(file string, line int) string {
+	if currentOptions().Deterministic {
+		return path.Base(file) + ":NN"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+var (
+	// goroutineRe matches goroutine IDs in panic stacks.
+	goroutineRe = regexp.MustCompile(`goroutine \d+`)
+	// argsRe matches arguments of calls in panic stacks, like "(0xc000012345, 0x3?)".
+	argsRe = regexp.MustCompile(`(?m)\([^()\n]+\)$`)
+	// placeRe matches places in code in panic stacks, like "\t/home/me/app/main.go:12 +0x1d".
+	placeRe = regexp.MustCompile(`(?m)^\t(?:.*/)?([^/\s]+):\d+(?: \+0x[0-9a-f]+)?$`)
+)
+
+// panicStack returns the stack as a string, normalized if the Deterministic option is set.
+func panicStack(stack []byte) string {
+	if !currentOptions().Deterministic {
+		return string(stack)
+	}
+	s := goroutineRe.ReplaceAllString(string(stack), "goroutine N")
+	s = argsRe.ReplaceAllString(s, "(...)")
+	return placeRe.ReplaceAllString(s, "\t$1:NN")
+}
@@ -0,0 +1,30 @@
+package errbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeterministicOutput(t *testing.T) {
+	DeterministicOutput(true)
+	defer DeterministicOutput(false)
+
+	err := Annotate(errors.New("boom"), "loading")
+	if s := err.Error(); s != "boom\n +--> loading\n    @ deterministic_test.go:NN (TestDeterministicOutput)\n" {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+
+	var perr error
+	func() {
+		defer Recover(&perr)
+		panic("boom")
+	}()
+	s := perr.Error()
+	if !strings.Contains(s, "goroutine N [running]:") || !strings.Contains(s, "\tdeterministic_test.go:NN\n") {
+		t.Errorf("expected normalized panic stack:\n%s", s)
+	}
+	if strings.Contains(s, "+0x") || strings.Contains(s, "(0x") {
+		t.Errorf("expected no addresses in panic stack:\n%s", s)
+	}
+}
@@ -4,110 +4,394 @@ Package errbox provides errors with stack trace, which can also be grouped (boxe
 package errbox
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"io"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
 
-// OmitPrefixFromTrace will SET package level variable filePrefix.
-// Later, when errors are printed out (Error() is called), stack trace is inspected.
-// Filename where the error occured is searched for the prefix, and everything before the prefix plus the prefix itself
-// is dropped from the filename.
-//
-// Why is this useful: suppose you have package called recombobulator, and you do not want to print out the path
-// to the current package in our error. You can achieve this by calling OmitPrefixFromTrace("recombobulator/").
-//
-// Beware, this variable is not mutex protected, therefore you should only set it ONCE, and then it should NOT be touched!
-func OmitPrefixFromTrace(pfx string) {
-	pfx = filepath.ToSlash(pfx)
-	filePrefix = pfx
-}
-
-// filePrefix will always be removed from the stack trace.
-// This variable is NOT mutex protected, therefore you should set it once at the beginning of your program, and then
-// it should NOT be touched again.
-var filePrefix string
-
-// ShowStack will SET package level variable showStack. This variable controls how errors are printed out.
-// Beware, this variable is not mutex protected, therefore you should only set it ONCE, and then it should NOT be touched!
-func ShowStack(show bool) {
-	showStack = show
-}
-
-// showStack controls if stack trace is printed out.
-var showStack = true
-
 // Box can store multiple errors, and also implements the error interface itself,
 // It is a mutex protected storage of other errors. Use it via Append, or directly via PushIf, or PushIfErr.
 type Box struct {
+	_      noCopy // go vet reports copies of the box
 	mu     sync.Mutex
-	errLis []*StackErr // list of errors encountered so far
+	errLis []*StackErr         // list of errors encountered so far
+	stack  toggle              // overrides the HideStack option (see ShowStack) for errors in the box
+	seen   map[string]struct{} // fingerprints of errors in the box, nil if the box does not deduplicate errors
+
+	wg  sync.WaitGroup // goroutines started by Go
+	sem chan struct{}  // limits the number of active goroutines started by Go, nil means no limit
+
+	onPush []func(err *StackErr)   // called for every error pushed into the box
+	hook   int                     // index of the onPush entry replaced by SetHook, plus one; zero if there is none
+	cancel context.CancelCauseFunc // cancels the context returned by WithContext
+	fatal  func(err error) bool    // decides which errors cancel the context, nil means all of them
+
+	limit    int  // maximum number of errors in the box, zero means unbounded
+	keepLast bool // keep the last errors instead of the first ones, when the limit is reached
+	dropped  int  // number of errors which were dropped because of the limit
 }
 
+// noCopy may be embedded into structs which must not be copied after the first use, so that go vet
+// (the copylocks checker) reports the copies. See https://golang.org/issues/8005#issuecomment-190753527.
+type noCopy struct{}
+
+// Lock is a no-op used by go vet.
+func (*noCopy) Lock() {}
+
+// Unlock is a no-op used by go vet.
+func (*noCopy) Unlock() {}
+
 // Append appends the error to the error of type *Box, and returns it.
 //
 // If the first parameter is nil, or is of different type, it is converted to the type Box.
 //
 // If err is nil, nothing happens (returns nil).
+//
+// Append locks both boxes, so it is safe to append to a box which is shared between goroutines.
 func Append(box, err error) error {
 	// noop if no error
-	if err == nil {
+	if isNil(err) {
 		return box
 	}
 	newBox := asBox(box)
+	newBox.pushErr(err) // flattens the err, if it is a box
+	return newBox
+}
 
-	// flatten the box
+// AppendNested works like Append, but if the err is a *Box, it is not flattened: it is added to the box
+// as a named group (see Box.AddGroup), so that the errors keep together, and the box keeps its identity
+// (errors pushed into it later show up in the group as well):
+//
+//	err = errbox.AppendNested(err, importFile(name), name)
+func AppendNested(box, err error, name string) error {
+	if isNil(err) {
+		return box
+	}
+	newBox := asBox(box)
 	if errBox, ok := err.(*Box); ok {
-		newBox.errLis = append(newBox.errLis, errBox.errLis...)
+		newBox.AddGroup(name, errBox)
 		return newBox
 	}
-
-	// err is not a *Box, convert it to the type *StackErr
-	newErr := WithStack(err)
-	newBox.errLis = append(newBox.errLis, newErr)
+	newBox.pushErr(err)
 	return newBox
 }
 
-// Errors returns copy of slice of errors encountered so far. Nil slice is returned if err is nil.
+// CloseAndAppend closes the closer, and if Close fails, annotates its error (see Annotate) and appends it
+// to the error pointed to by the err (see Append). It is meant to be deferred, so that the error returned
+// by Close is not silently dropped:
+//
+//	func write(name string) (err error) {
+//		f, err := os.Create(name)
+//		if err != nil {
+//			return err
+//		}
+//		defer errbox.CloseAndAppend(&err, f, "closing %s", name)
+//		// ...
+//	}
+//
+// If the err points to nil, it is set to the annotated error of Close, otherwise both errors are kept in a *Box.
+func CloseAndAppend(err *error, closer io.Closer, message string, args ...interface{}) {
+	closeErr := closer.Close()
+	if closeErr == nil {
+		return
+	}
+	closeErr = annotateErr(3, closeErr, message, args...)
+	if isNil(*err) {
+		*err = closeErr
+		return
+	}
+	*err = Append(*err, closeErr)
+}
+
+// Errors returns copy of slice of errors encountered so far. Nil slice is returned if err is nil
+// (including a nil *Box, or any other nil pointer stored in the error interface).
 //
 // If err is of type *Box, returns slice with all errors appended to the *Box.
 //
 // If err is not of type *Box, slice containing err is returned.
 func Errors(err error) []error {
-	if err == nil {
+	if isNil(err) {
 		return nil
 	}
 	b := asBox(err)
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	l := len(b.errLis)
+	entries := b.entries()
+	l := len(entries)
 	if l == 0 {
 		return nil
 	}
 
 	errs := make([]error, l)
-	for i := range b.errLis {
-		errs[i] = b.errLis[i]
+	for i := range entries {
+		errs[i] = entries[i]
 	}
 	return errs
 }
 
+// Len returns the number of errors in the box, without copying them (see Errors). A nil box is empty.
+// A non-empty group (see Group) counts as one error.
+func (b *Box) Len() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries())
+}
+
+// IsEmpty returns true if the box contains no errors.
+func (b *Box) IsEmpty() bool {
+	return b.Len() == 0
+}
+
+// Filter returns a new box with errors for which the keep function returned true. The box itself is not changed.
+//
+// The keep function is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) Filter(keep func(err error) bool) *Box {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	nb := NewBox()
+	nb.stack = b.stack
+	for _, err := range b.entries() {
+		if keep(err) {
+			nb.errLis = append(nb.errLis, err)
+		}
+	}
+	return nb
+}
+
+// Partition splits the box into two new boxes: errors for which the fn returned true, and the rest.
+// For example, retryable errors can be separated from fatal ones, and only the retryable subset re-queued.
+// The box itself is not changed.
+//
+// The fn is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) Partition(fn func(err error) bool) (matched, rest *Box) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	matched, rest = NewBox(), NewBox()
+	matched.stack, rest.stack = b.stack, b.stack
+	for _, err := range b.entries() {
+		if fn(err) {
+			matched.errLis = append(matched.errLis, err)
+		} else {
+			rest.errLis = append(rest.errLis, err)
+		}
+	}
+	return matched, rest
+}
+
+// RemoveIf removes all errors for which the remove function returned true, and returns how many errors were removed.
+// For example, it can be used to drop all context.Canceled errors before deciding whether the batch actually failed:
+//
+//	box.RemoveIf(func(err error) bool { return errors.Is(err, context.Canceled) })
+//
+// The remove function is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) RemoveIf(remove func(err error) bool) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	kept := b.errLis[:0]
+	for _, err := range b.errLis {
+		if err.isEmptyGroup() || !remove(err) { // empty groups are kept, they may receive errors later
+			kept = append(kept, err)
+		} else {
+			b.forget(err)
+		}
+	}
+	removed := len(b.errLis) - len(kept)
+	for i := len(kept); i < len(b.errLis); i++ {
+		b.errLis[i] = nil // do not leak removed errors
+	}
+	b.errLis = kept
+	return removed
+}
+
+// Map returns a new box with errors returned by the fn, which is called for every error in the box, in order.
+// It can be used to rewrite or re-annotate all errors in one pass, for example:
+//
+//	box = box.Map(func(err error) error { return errbox.WithField(err, "request_id", id) })
+//
+// If the fn returns nil, the error is dropped. If the fn returns a *Box, its errors are added to the new box.
+// The box itself is not changed (however, the fn may modify the errors).
+func (b *Box) Map(fn func(err error) error) *Box {
+	nb := NewBox()
+	b.mu.Lock()
+	nb.stack = b.stack
+	b.mu.Unlock()
+
+	var mapped error = nb
+	for _, err := range Errors(b) {
+		mapped = Append(mapped, fn(err))
+	}
+	return nb
+}
+
+// Sort sorts errors in the box by the less function, so that they can be ordered by severity, code or timestamp
+// before rendering a report. The sort is stable, errors which are equal keep the order in which they were pushed.
+//
+// The less function is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) Sort(less func(a, b error) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// empty groups go last, so that the less function is called with errors only
+	sort.SliceStable(b.errLis, func(i, j int) bool {
+		a, c := b.errLis[i], b.errLis[j]
+		if a.isEmptyGroup() || c.isEmptyGroup() {
+			return !a.isEmptyGroup() && c.isEmptyGroup()
+		}
+		return less(a, c)
+	})
+}
+
+// Merge appends all errors of the other box to the box. Errors are appended as they are, with their annotations
+// and fields, and errors dropped by the other box (see NewBoxWithCap) are counted as dropped by the box as well.
+//
+// The other box is locked while its errors are read, so it is safe to merge a box which is still in use.
+// Merging the box into itself does nothing.
+func (b *Box) Merge(other *Box) {
+	if other == nil || b.isIn(other) {
+		return
+	}
+	other.mu.Lock()
+	errs := make([]*StackErr, len(other.errLis))
+	copy(errs, other.errLis)
+	dropped := other.dropped
+	other.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, err := range errs {
+		b.push(err)
+	}
+	b.dropped += dropped
+}
+
+// GroupBy splits errors in the box into groups by the key returned by the fn, so that a report can show
+// "3 timeouts, 2 permission errors, 1 parse error" instead of a flat list. Errors keep their order in every group.
+// The box itself is not changed.
+func (b *Box) GroupBy(fn func(err error) string) map[string]*Box {
+	b.mu.Lock()
+	stack := b.stack
+	b.mu.Unlock()
+
+	groups := make(map[string]*Box)
+	for _, err := range Errors(b) {
+		key := fn(err)
+		g, ok := groups[key]
+		if !ok {
+			g = NewBox()
+			g.stack = stack
+			groups[key] = g
+		}
+		g.errLis = append(g.errLis, WithStack(err))
+	}
+	return groups
+}
+
+// Snapshot returns a copy of the box, which is safe to hand over to a reporting goroutine while the box keeps
+// receiving errors. The snapshot is independent of the box: errors pushed to the box (or annotated in the box)
+// later do not show up in the snapshot, and vice versa.
+func (b *Box) Snapshot() *Box {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	nb := NewBox()
+	nb.stack = b.stack
+	nb.dropped = b.dropped
+	nb.keepLast = b.keepLast
+	nb.errLis = make([]*StackErr, len(b.errLis))
+	for i, err := range b.errLis {
+		nb.errLis[i] = err.clone()
+	}
+	return nb
+}
+
+// Reset removes all errors from the box, so that long-running loops can reuse one box per iteration
+// without reallocating. Settings of the box (like WithDedup, or the cap of NewBoxWithCap) are kept.
+func (b *Box) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.errLis {
+		b.errLis[i] = nil // do not leak removed errors
+	}
+	b.errLis = b.errLis[:0]
+	b.dropped = 0
+	if b.seen != nil {
+		b.seen = make(map[string]struct{})
+	}
+}
+
 // NewBox returns a new Box pointer. The Box should never be copied, because it contains a mutex.
 func NewBox() *Box {
 	box := new(Box)
 	return box
 }
 
+// isNil returns true if the err is nil, or if it is an interface holding a nil pointer (or other nil value),
+// like a nil *Box returned as error. Such errors are treated as no error.
+func isNil(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return true
+	case *StackErr:
+		return e == nil
+	case *Box:
+		return e == nil
+	}
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isIn returns true if the box is the err, or if the err contains the box (see Walk, and also empty groups,
+// which are skipped by Walk). Such errors are not added to the box, because the box would contain itself,
+// and it could not be printed out.
+func (b *Box) isIn(err error) bool {
+	found := false
+	Walk(err, func(e error) bool {
+		eb, ok := e.(*Box)
+		found = ok && (eb == b || eb.hasGroup(b))
+		return !found
+	})
+	return found
+}
+
+// hasGroup returns true if the sub box is a group of the box, or a group of any of its groups, even if it is empty.
+func (b *Box) hasGroup(sub *Box) bool {
+	b.mu.Lock()
+	var groups []*Box
+	for _, err := range b.errLis {
+		if err.group != "" {
+			groups = append(groups, err.cause.(*Box))
+		}
+	}
+	b.mu.Unlock()
+	for _, g := range groups {
+		if g == sub || g.hasGroup(sub) {
+			return true
+		}
+	}
+	return false
+}
+
 // asBox converts the error to a *Box. Nil is also converted to a new box.
 func asBox(err error) *Box {
-	if err == nil {
+	if isNil(err) {
 		return NewBox()
 	}
 	if b, ok := err.(*Box); ok {
 		return b
 	}
+	if sb, ok := err.(*ShardedBox); ok {
+		return sb.Box()
+	}
 	// underlying error is not a Box, convert it
 	b := NewBox()
 	this := WithStack(err)
@@ -118,23 +402,19 @@ func asBox(err error) *Box {
 // PushIf adds the error to the Box, and returns true if the first parameter was not nil. If the error is nil, returns false.
 // If you need to return the annotated error, use PushIfErr instead.
 func (b *Box) PushIf(err error, message string, args ...interface{}) bool {
-	// return on no error
-	if err == nil {
+	// return on no error, refuse to create a cycle
+	if isNil(err) || b.isIn(err) {
 		return false
 	}
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	// this error and last error, both as boxed errors
 	// annotate this error (give it stack trace and additional message
-	this := WithStack(err)
+	this, isNew := withStack(err)
 	this.annotate(2, message, args...)
-	last := b.last()
-
-	// append this error if last error was different
-	if this != last {
-		b.errLis = append(b.errLis, this)
+	if isNew {
+		created(this)
 	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.push(this)
 
 	// return the error
 	return true
@@ -143,25 +423,289 @@ func (b *Box) PushIf(err error, message string, args ...interface{}) bool {
 // PushIfErr adds the error to the Box, and returns the annotated error if the first parameter was not nil. If the error is nil, returns nil.
 func (b *Box) PushIfErr(err error, message string, args ...interface{}) error {
 	// return on no error
-	if err == nil {
+	if isNil(err) {
 		return nil
 	}
+	if b.isIn(err) {
+		return err // refuse to create a cycle
+	}
+	// annotate this error (give it stack trace and additional message
+	this, isNew := withStack(err)
+	this.annotate(2, message, args...)
+	if isNew {
+		created(this)
+	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.push(this)
+
+	// return the error
+	return this
+}
 
-	// this error and last error, both as boxed errors
+// PushIfLazy works like PushIf, but the message is formatted only when it is needed (see AnnotateLazy).
+func (b *Box) PushIfLazy(err error, message string, args ...interface{}) bool {
+	// return on no error, refuse to create a cycle
+	if isNil(err) || b.isIn(err) {
+		return false
+	}
 	// annotate this error (give it stack trace and additional message
-	this := WithStack(err)
+	this, isNew := withStack(err)
+	this.annotateLazy(2, message, args...)
+	if isNew {
+		created(this)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.push(this)
+	return true
+}
+
+// PushTagged works like PushIf, and also attaches the tags to the error (see StackErr.Tags), so that entries
+// in the box can carry which shard, file, or customer produced them. Tags are shown when the box is printed out,
+// and the box can be filtered by them (see Tagged).
+func (b *Box) PushTagged(err error, tags map[string]string, message string, args ...interface{}) bool {
+	// return on no error, refuse to create a cycle
+	if isNil(err) || b.isIn(err) {
+		return false
+	}
+	// annotate this error (give it stack trace and additional message
+	this, isNew := withStack(err)
 	this.annotate(2, message, args...)
-	last := b.last()
+	this.tag(tags)
+	if isNew {
+		created(this)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.push(this)
+	return true
+}
+
+// Tagged returns a new box with errors which have the tag with the value (see PushTagged).
+// The box itself is not changed.
+func (b *Box) Tagged(tag, value string) *Box {
+	return b.Filter(func(err error) bool {
+		v, ok := WithStack(err).Tags()[tag]
+		return ok && v == value
+	})
+}
+
+// Group returns a named sub-box of the box, creating it if it does not exist yet. Errors pushed into the sub-box
+// are kept together, and when the box is printed out, the group is rendered as an indented tree
+// under its name, instead of being flattened (see Append), for example:
+//
+//	box.Group("data.csv").PushIf(err, "line %d", n)
+//	box.Group("config.yaml").PushIf(err, "")
+//
+// A group counts as one error of the box, and it is omitted while it is empty.
+// The group is shared: calling Group with the same name again returns the same sub-box.
+// Empty name is replaced by "(unnamed)".
+func (b *Box) Group(name string) *Box {
+	name = groupName(name)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g := b.group(name); g != nil {
+		return g
+	}
+	sub := NewBox()
+	b.errLis = append(b.errLis, &StackErr{cause: sub, group: name})
+	return sub
+}
+
+// AddGroup adds the sub box to the box as a named group (see Group). If the group with the name already exists,
+// errors of the sub box are merged into it instead (see Merge).
+func (b *Box) AddGroup(name string, sub *Box) {
+	if sub == nil || b.isIn(sub) {
+		return
+	}
+	name = groupName(name)
+	b.mu.Lock()
+	g := b.group(name)
+	if g == nil {
+		b.errLis = append(b.errLis, &StackErr{cause: sub, group: name})
+	}
+	b.mu.Unlock()
+	if g != nil {
+		g.Merge(sub)
+	}
+}
+
+// groupName returns the name of a group, which is never empty.
+func groupName(name string) string {
+	if name == "" {
+		return "(unnamed)"
+	}
+	return name
+}
+
+// group returns the sub-box with the name, or nil if there is none. The caller must hold the lock.
+func (b *Box) group(name string) *Box {
+	for _, err := range b.errLis {
+		if err.group == name {
+			return err.cause.(*Box)
+		}
+	}
+	return nil
+}
+
+// list returns a copy of errors in the box, without empty groups, so that they can be modified without the lock.
+func (b *Box) list() []*StackErr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*StackErr(nil), b.entries()...)
+}
+
+// leaves returns a copy of errors in the box (see list), with errors of groups in place of the groups,
+// recursively, so that annotations and fields added to all errors of the box are not lost on groups.
+func (b *Box) leaves() []*StackErr {
+	var errs []*StackErr
+	for _, err := range b.list() {
+		if err.group != "" {
+			errs = append(errs, err.cause.(*Box).leaves()...)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
 
+// entries returns errors in the box, without empty groups. The caller must hold the lock.
+func (b *Box) entries() []*StackErr {
+	for i, err := range b.errLis {
+		if err.isEmptyGroup() {
+			// slow path, copy non-empty entries
+			entries := append([]*StackErr(nil), b.errLis[:i]...)
+			for _, err := range b.errLis[i+1:] {
+				if !err.isEmptyGroup() {
+					entries = append(entries, err)
+				}
+			}
+			return entries
+		}
+	}
+	return b.errLis
+}
+
+// push appends the error to the box, unless it is the same as the last error,
+// or unless the box deduplicates errors (see WithDedup) and already contains an error with the same fingerprint.
+// The caller must hold the lock.
+func (b *Box) push(this *StackErr) {
 	// append this error if last error was different
-	if this != last {
-		b.errLis = append(b.errLis, this)
+	if this == b.last() {
+		return
+	}
+	if b.seen != nil {
+		fp := Fingerprint(this)
+		if _, ok := b.seen[fp]; ok {
+			return
+		}
+		b.seen[fp] = struct{}{}
 	}
+	for _, fn := range b.onPush {
+		fn(this)
+	}
+	pushed(this)
+	if b.cancel != nil && (b.fatal == nil || b.fatal(this)) {
+		b.cancel(this)
+	}
+	if b.limit > 0 && len(b.errLis) >= b.limit {
+		b.dropped++
+		if !b.keepLast {
+			b.forget(this)
+			return
+		}
+		b.forget(b.errLis[0])
+		copy(b.errLis, b.errLis[1:])
+		b.errLis = b.errLis[:len(b.errLis)-1]
+	}
+	b.errLis = append(b.errLis, this)
+}
 
-	// return the error
-	return this
+// OnPush registers the fn, which is called with every error pushed into the box (in any way), so that errors can be
+// immediately forwarded to a logger or a metrics sink, instead of only being visible when the whole box is printed
+// out at the end. Errors which are not added to the box because they are duplicates (see WithDedup) are not
+// forwarded; errors which do not fit into a bounded box (see NewBoxWithCap) are.
+//
+// The fn is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) OnPush(fn func(err *StackErr)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onPush = append(b.onPush, fn)
+}
+
+// SetHook sets the fn, which is called with every error pushed or appended into the box (see OnPush), so that
+// a long-running batch job can stream failures to a progress UI, while the box still produces the final report:
+//
+//	box.SetHook(func(err *errbox.StackErr) { progress.Failed(errbox.Cause(err)) })
+//
+// Unlike OnPush, SetHook replaces the hook set before (in its place among functions registered by OnPush);
+// nil removes it. The fn is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) SetHook(fn func(err *StackErr)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if fn == nil {
+		fn = func(err *StackErr) {}
+	}
+	if b.hook == 0 {
+		b.onPush = append(b.onPush, fn)
+		b.hook = len(b.onPush)
+		return
+	}
+	b.onPush[b.hook-1] = fn
+}
+
+// forget removes fingerprint of the error from the box, so that the same error can be pushed again.
+// The caller must hold the lock.
+func (b *Box) forget(err *StackErr) {
+	if b.seen != nil {
+		delete(b.seen, Fingerprint(err))
+	}
+}
+
+// NewBoxWithCap returns a new Box pointer, which keeps at most n errors. By default, the first n errors are kept
+// (see WithKeepLast), and the rest is only counted (see Dropped), and rendered as "... and N more errors",
+// so that unbounded batch jobs can not exhaust memory via error accumulation.
+//
+// If n is not positive, the box is not bounded.
+func NewBoxWithCap(n int) *Box {
+	box := NewBox()
+	box.limit = n
+	return box
+}
+
+// WithKeepLast controls which errors are kept by a bounded box (see NewBoxWithCap), and returns the box back.
+// When turned on, the last n errors are kept, and older errors are dropped.
+func (b *Box) WithKeepLast(on bool) *Box {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.keepLast = on
+	return b
+}
+
+// Dropped returns the number of errors which did not fit into a bounded box (see NewBoxWithCap).
+func (b *Box) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// WithDedup turns on (or off) deduplication of errors in the box, and returns the box back.
+// When turned on, an error is not added to the box if the box already contains an error with the same
+// Fingerprint (the same cause, which happened at the same place), so that retry loops do not flood the box
+// with identical errors. Errors already in the box are not deduplicated.
+func (b *Box) WithDedup(on bool) *Box {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !on {
+		b.seen = nil
+		return b
+	}
+	b.seen = make(map[string]struct{})
+	for _, err := range b.errLis {
+		b.seen[Fingerprint(err)] = struct{}{}
+	}
+	return b
 }
 
 // last returns the last error encountered, or nil if no error were encountered yet.
@@ -172,49 +716,138 @@ func (b *Box) last() *StackErr {
 	return b.errLis[len(b.errLis)-1]
 }
 
-// Error implements the error interface
+// WithStackOutput overrides the HideStack option (see ShowStack) for all errors in the box, and returns the box back.
+// Errors which override the option themselves (see StackErr.WithStackOutput) are not affected.
+//
+// Why is this useful: one service can print full traces to internal logs, and stack-free messages
+// to API responses, which would not be possible with the HideStack option (see ShowStack) alone.
+func (b *Box) WithStackOutput(show bool) *Box {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stack = toggleOf(show)
+	return b
+}
+
+// Error implements the error interface. A nil box returns empty string.
 func (b *Box) Error() string {
+	if b == nil {
+		return ""
+	}
+	return b.render(inherit)
+}
+
+// render returns the box as a string, stack is the ShowStack override inherited from the parent box
+// (see Group), if any.
+func (b *Box) render(stack toggle) string {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if len(b.errLis) == 0 {
+	entries := b.entries()
+	if len(entries) == 0 {
 		return ""
 	}
 
-	if len(b.errLis) == 1 {
-		return b.errLis[0].Error()
+	showStack := b.stack.resolve(stack.resolve(!currentOptions().HideStack))
+	if len(entries) == 1 && b.dropped == 0 {
+		return entries[0].render(entries[0].stack.resolve(showStack))
+	}
+
+	// errors dropped from the beginning of the box shift the numbering
+	first := 1
+	if b.keepLast {
+		first += b.dropped
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Got %d errors:\n", len(b.errLis)))
-	for i, err := range b.errLis {
+	sb.WriteString(fmt.Sprintf("Got %d errors:\n", len(entries)+b.dropped))
+	if b.keepLast && b.dropped > 0 {
 		sb.WriteString("----------------------------\n")
-		sb.WriteString(fmt.Sprintf("# %d\n", i+1))
-		sb.WriteString(err.Error())
+		sb.WriteString(fmt.Sprintf("... %d earlier errors\n\n", b.dropped))
+	}
+	for i, err := range entries {
+		sb.WriteString("----------------------------\n")
+		sb.WriteString(fmt.Sprintf("# %d%s%s\n", i+first, formatSeverity(err), formatTags(err.Tags())))
+		sb.WriteString(err.render(err.stack.resolve(showStack)))
 		sb.WriteString("\n")
 	}
+	if !b.keepLast && b.dropped > 0 {
+		sb.WriteString("----------------------------\n")
+		sb.WriteString(fmt.Sprintf("... and %d more errors\n", b.dropped))
+	}
 	return sb.String()
 }
 
 // IsInside checks whether the err is the target (think errors.Is).
 // When the err is *Box, it returns true if any of the errors in the err is the target.
+//
+// Nested boxes are searched as well, including boxes wrapped by other errors (like WithStack, or fmt.Errorf
+// with %w), groups (see Box.Group), and suppressed errors (see Walk). Because Box implements Is, the result is
+// the same as the result of errors.Is(err, target); IsInside is kept for readability and compatibility.
 func IsInside(err error, target error) bool {
-	// if the error is not a *Box, simply use errors package
-	// otherwise, cycle through all errors
-	if be, ok := err.(*Box); ok {
-		for _, se := range be.errLis {
-			if errors.Is(se, target) {
-				return true
-			}
+	found := false
+	Walk(err, func(e error) bool {
+		found = errors.Is(e, target)
+		return !found
+	})
+	return found
+}
+
+// Is makes the box match errors stored in it (including errors in groups and nested boxes), so that errors.Is
+// works with boxes, even if they are wrapped by other errors. See IsInside.
+func (b *Box) Is(target error) bool {
+	for _, err := range Errors(b) {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As makes the box match errors stored in it, see errors.As. The first matching error in the box wins.
+func (b *Box) As(target interface{}) bool {
+	for _, err := range Errors(b) {
+		if errors.As(err, target) {
+			return true
 		}
-		return false
-	} else {
-		return errors.Is(err, target)
 	}
+	return false
+}
+
+// IsInsideAny returns true if any of the targets is inside the err (see IsInside).
+func IsInsideAny(err error, targets ...error) bool {
+	for _, target := range targets {
+		if IsInside(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInsideAll returns true if all of the targets are inside the err (see IsInside).
+// With a *Box, every target can be found in a different error of the box. Returns true if there are no targets.
+func IsInsideAll(err error, targets ...error) bool {
+	for _, target := range targets {
+		if !IsInside(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns how many errors in the box are the target (think errors.Is), so that batch jobs can report
+// "17 of 500 items failed with ErrNotFound".
+func (b *Box) Count(target error) int {
+	n := 0
+	for _, err := range Errors(b) {
+		if errors.Is(err, target) {
+			n++
+		}
+	}
+	return n
 }
 
 // String implements Stringer interface
-func (b Box) String() string {
+func (b *Box) String() string {
 	return b.Error()
 }
 
@@ -241,17 +874,37 @@ func Run(accFunc AccumulateFunc) *Box {
 // This would make sure that calls are made up to the point when one of the functions fails.
 // Variable err would then contain the first error which was encountered.
 func (b *Box) Then(accFunc AccumulateFunc) *Box {
-	if len(b.errLis) > 0 {
+	if !b.IsEmpty() {
 		return b
 	}
 	b.PushIf(accFunc(), "")
 	return b
 }
 
-// First returns the first error that was encountered.
+// First returns the first error that was encountered, or nil if the box is empty.
 func (b *Box) First() error {
-	if len(b.errLis) == 0 {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.entries()
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[0]
+}
+
+// Last returns the last error that was encountered, or nil if the box is empty.
+func (b *Box) Last() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.entries()
+	if len(entries) == 0 {
 		return nil
 	}
-	return b.errLis[0]
+	return entries[len(entries)-1]
 }
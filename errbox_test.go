@@ -1,8 +1,14 @@
 package errbox
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -93,3 +99,695 @@ func TestAppend(t *testing.T) {
 	err = Append(err, Annotate(fmt.Errorf("boom"), ""))
 	// TODO add tests
 }
+
+func TestAppendNested(t *testing.T) {
+	sub := NewBox()
+	sub.PushIf(fmt.Errorf("line 1"), "")
+	err := AppendNested(fmt.Errorf("kablam"), sub, "data.csv")
+	sub.PushIf(fmt.Errorf("line 2"), "")
+	err = AppendNested(err, fmt.Errorf("boom"), "ignored")
+
+	errs := Errors(err)
+	if len(errs) != 3 || errs[1].(*StackErr).cause != sub {
+		t.Fatalf("expected the sub box as a group, got:\n%v", err)
+	}
+	if s := err.Error(); !strings.Contains(s, "data.csv") || !strings.Contains(s, "line 2") {
+		t.Errorf("expected the group with both errors, got:\n%s", s)
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	box := NewBox()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = Append(box, fmt.Errorf("boom %d/%d", i, j))
+				other := NewBox()
+				other.PushIf(fmt.Errorf("kablam"), "")
+				_ = Append(box, other)
+				_ = box.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := box.Len(); n != 800 {
+		t.Errorf("expected 800 errors, got %d", n)
+	}
+}
+
+func TestAnnotateBoxConcurrent(t *testing.T) {
+	box := NewBox()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			box.PushIf(fmt.Errorf("boom %d", i), "")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		origin := CaptureOrigin()
+		for i := 0; i < 100; i++ {
+			_ = Annotate(box, "annotated")
+			_ = AnnotateLazy(box, "annotated %d", i)
+			_ = WithFields(box, map[string]interface{}{"attempt": i})
+			_ = origin.Attach(box)
+		}
+	}()
+	wg.Wait()
+	if n := box.Len(); n != 100 {
+		t.Errorf("expected 100 errors, got %d", n)
+	}
+}
+
+func TestSanitizeTrace(t *testing.T) {
+	SanitizeTraceRegexp(regexp.MustCompile(`^.*/`), "src/")
+	defer SanitizeTraceWith(nil)
+	ShowStack(true)
+
+	err := Annotate(fmt.Errorf("boom"), "")
+	if s := err.Error(); !strings.Contains(s, "@ src/errbox_test.go:") {
+		t.Errorf("file name was not sanitized: %s", s)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(DefaultOptions())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Configure(Options{HideStack: j%2 == 0, FilePrefix: "errbox/"})
+				_ = Annotate(fmt.Errorf("boom"), "attempt %d", j).Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	Configure(Options{HideStack: true})
+	if s := Annotate(fmt.Errorf("boom"), "msg").Error(); strings.Contains(s, "@") {
+		t.Errorf("stack trace should not be printed: %s", s)
+	}
+	if !CurrentOptions().HideStack {
+		t.Errorf("expected HideStack to be true")
+	}
+
+	// changing one option keeps the others, when starting from the current options
+	Configure(DefaultOptions())
+	opts := CurrentOptions()
+	opts.FilePrefix = "errbox/"
+	Configure(opts)
+	if current := CurrentOptions(); current.HideStack || current.FilePrefix != "errbox/" {
+		t.Errorf("expected HideStack to be kept, got %+v", current)
+	}
+	// options which are not set are the defaults
+	Configure(Options{FilePrefix: "errbox/"})
+	if s := Annotate(fmt.Errorf("boom"), "msg").Error(); !strings.Contains(s, "@") {
+		t.Errorf("stack trace should be printed: %s", s)
+	}
+}
+
+func TestStackOutput(t *testing.T) {
+	ShowStack(true)
+
+	b := NewBox().WithStackOutput(false)
+	b.PushIf(fmt.Errorf("boom"), "hidden")
+	if s := b.Error(); strings.Contains(s, "@") {
+		t.Errorf("box should not print stack trace: %s", s)
+	}
+
+	err := WithStack(Annotate(fmt.Errorf("boom"), "shown")).WithStackOutput(true)
+	b.PushIf(err, "")
+	if s := b.Error(); strings.Count(s, "@") != 2 {
+		t.Errorf("error should override the box: %s", s)
+	}
+	if s := WithStack(b.last()).WithStackOutput(false).Error(); strings.Contains(s, "@") {
+		t.Errorf("error should not print stack trace: %s", s)
+	}
+}
+
+func TestRepeatedAnnotation(t *testing.T) {
+	ShowStack(true)
+
+	err := fmt.Errorf("boom")
+	for i := 0; i < 3; i++ {
+		err = Annotate(err, "retrying")
+	}
+	s := err.Error()
+	if strings.Count(s, "retrying") != 1 || !strings.Contains(s, "retrying (x3)") {
+		t.Errorf("repeated annotations were not collapsed: %s", s)
+	}
+}
+
+func TestStampBuildInfo(t *testing.T) {
+	Configure(Options{StampBuildInfo: true})
+	defer Configure(DefaultOptions())
+
+	err := WithStack(fmt.Errorf("boom"))
+	if v := err.StringField(FieldBuildVersion); v == "" {
+		t.Errorf("expected build version to be set, got: %#v", err.Fields())
+	}
+}
+
+func TestLen(t *testing.T) {
+	b := NewBox()
+	if !b.IsEmpty() || b.Len() != 0 {
+		t.Errorf("expected empty box")
+	}
+	b.PushIf(fmt.Errorf("one"), "")
+	b.PushIf(fmt.Errorf("two"), "")
+	if b.IsEmpty() || b.Len() != 2 {
+		t.Errorf("expected 2 errors, got %d", b.Len())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	b := NewBox()
+	b.PushIf(context.Canceled, "")
+	b.PushIf(fmt.Errorf("boom"), "")
+	b.PushIf(fmt.Errorf("stopped: %w", context.Canceled), "")
+
+	isCanceled := func(err error) bool { return errors.Is(err, context.Canceled) }
+	if f := b.Filter(isCanceled); f.Len() != 2 || b.Len() != 3 {
+		t.Errorf("expected 2 filtered errors and the box intact, got %d and %d", f.Len(), b.Len())
+	}
+	if n := b.RemoveIf(isCanceled); n != 2 || b.Len() != 1 {
+		t.Errorf("expected 2 removed errors, got %d, %d left", n, b.Len())
+	}
+	if IsInside(b, context.Canceled) {
+		t.Errorf("expected no canceled errors in the box")
+	}
+}
+
+func TestMap(t *testing.T) {
+	b := NewBox()
+	b.PushIf(fmt.Errorf("one"), "")
+	b.PushIf(fmt.Errorf("two"), "")
+	b.PushIf(fmt.Errorf("drop me"), "")
+
+	m := b.Map(func(err error) error {
+		if Cause(err).Error() == "drop me" {
+			return nil
+		}
+		return WithField(err, "request_id", "r-1")
+	})
+	if m.Len() != 2 || b.Len() != 3 {
+		t.Errorf("expected 2 mapped errors and the box intact, got %d and %d", m.Len(), b.Len())
+	}
+	for _, err := range Errors(m) {
+		if StringFieldOf(err, "request_id") != "r-1" {
+			t.Errorf("expected request_id on %v", err)
+		}
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	b := NewBox()
+	if b.First() != nil || b.Last() != nil {
+		t.Errorf("expected nil errors from empty box")
+	}
+	first, last := fmt.Errorf("first"), fmt.Errorf("last")
+	b.PushIf(first, "")
+	b.PushIf(fmt.Errorf("middle"), "")
+	b.PushIf(last, "")
+	if !errors.Is(b.First(), first) || !errors.Is(b.Last(), last) {
+		t.Errorf("unexpected first or last error: %v, %v", b.First(), b.Last())
+	}
+}
+
+func TestDedup(t *testing.T) {
+	b := NewBox().WithDedup(true)
+	for i := 0; i < 3; i++ {
+		b.PushIf(fmt.Errorf("timeout"), "attempt %d", i)
+	}
+	b.PushIf(fmt.Errorf("timeout"), "somewhere else")
+	if b.Len() != 2 {
+		t.Errorf("expected 2 errors, got %d: %s", b.Len(), b)
+	}
+	if Fingerprint(b.First()) == Fingerprint(b.Last()) {
+		t.Errorf("expected different fingerprints")
+	}
+}
+
+func TestBoxWithCap(t *testing.T) {
+	b := NewBoxWithCap(2)
+	for i := 0; i < 5; i++ {
+		b.PushIf(fmt.Errorf("error %d", i), "")
+	}
+	if b.Len() != 2 || b.Dropped() != 3 {
+		t.Errorf("expected 2 kept and 3 dropped errors, got %d and %d", b.Len(), b.Dropped())
+	}
+	if s := b.Error(); !strings.Contains(s, "Got 5 errors") || !strings.Contains(s, "... and 3 more errors") {
+		t.Errorf("unexpected rendering: %s", s)
+	}
+
+	b = NewBoxWithCap(2).WithKeepLast(true)
+	for i := 0; i < 5; i++ {
+		b.PushIf(fmt.Errorf("error %d", i), "")
+	}
+	if Cause(b.First()).Error() != "error 3" || Cause(b.Last()).Error() != "error 4" {
+		t.Errorf("expected the last errors to be kept: %s", b)
+	}
+	if s := b.Error(); !strings.Contains(s, "... 3 earlier errors") || !strings.Contains(s, "# 5") {
+		t.Errorf("unexpected rendering: %s", s)
+	}
+}
+
+func TestSort(t *testing.T) {
+	b := NewBox()
+	for _, msg := range []string{"c", "a", "b"} {
+		b.PushIf(errors.New(msg), "")
+	}
+	b.Sort(func(x, y error) bool { return Cause(x).Error() < Cause(y).Error() })
+	var got []string
+	for _, err := range Errors(b) {
+		got = append(got, Cause(err).Error())
+	}
+	if strings.Join(got, "") != "abc" {
+		t.Errorf("unexpected order: %v", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := NewBox(), NewBoxWithCap(1)
+	a.PushIf(fmt.Errorf("one"), "")
+	b.PushIf(WithField(fmt.Errorf("two"), "shard", 2), "pushed to b")
+	b.PushIf(fmt.Errorf("three"), "")
+
+	a.Merge(b)
+	a.Merge(a)
+	if a.Len() != 2 || a.Dropped() != 1 {
+		t.Errorf("expected 2 errors and 1 dropped, got %d and %d", a.Len(), a.Dropped())
+	}
+	if shard, _ := Field[int](a.Last(), "shard"); shard != 2 || !strings.Contains(a.Last().Error(), "pushed to b") {
+		t.Errorf("expected metadata to be preserved: %s", a.Last())
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	b := NewBox()
+	b.PushIf(context.DeadlineExceeded, "")
+	b.PushIf(fmt.Errorf("parse error"), "")
+	b.PushIf(fmt.Errorf("fetching: %w", context.DeadlineExceeded), "")
+
+	groups := b.GroupBy(func(err error) string {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "timeout"
+		}
+		return "other"
+	})
+	if len(groups) != 2 || groups["timeout"].Len() != 2 || groups["other"].Len() != 1 {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	b := NewBox()
+	b.PushIf(fmt.Errorf("one"), "")
+	snap := b.Snapshot()
+
+	b.PushIf(fmt.Errorf("two"), "")
+	Annotate(b, "annotated later")
+	WithFields(b, map[string]interface{}{"late": true})
+	if snap.Len() != 1 {
+		t.Errorf("expected 1 error in the snapshot, got %d", snap.Len())
+	}
+	if s := snap.Error(); strings.Contains(s, "annotated later") {
+		t.Errorf("snapshot should not see later annotations: %s", s)
+	}
+	if _, ok := FieldOf(snap, "late"); ok {
+		t.Errorf("snapshot should not see later fields")
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	b := NewBox()
+	err := b.PushIfErr(fmt.Errorf("one"), "")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = Annotate(err, "attempt %d", i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = b.Snapshot().Error()
+		_ = WithStack(err).Annotations()
+	}
+	<-done
+	if n := WithStack(err).Depth(); n != 101 {
+		t.Errorf("expected 101 annotations, got %d", n)
+	}
+}
+
+func TestReset(t *testing.T) {
+	b := NewBoxWithCap(1).WithDedup(true)
+	b.PushIf(fmt.Errorf("boom"), "")
+	b.PushIf(fmt.Errorf("bang"), "")
+	b.Reset()
+	if !b.IsEmpty() || b.Dropped() != 0 || b.Error() != "" {
+		t.Errorf("expected empty box after reset: %s", b)
+	}
+	for i := 0; i < 3; i++ {
+		b.PushIf(fmt.Errorf("boom"), "")
+	}
+	if b.Len() != 1 || b.Dropped() != 0 {
+		t.Errorf("expected settings to survive reset, got %d errors and %d dropped", b.Len(), b.Dropped())
+	}
+}
+
+func TestGroup(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+
+	b := NewBox()
+	b.PushIf(fmt.Errorf("top level"), "")
+	b.Group("data.csv").PushIf(fmt.Errorf("bad value"), "line %d", 3)
+	b.Group("data.csv").PushIf(fmt.Errorf("bad value"), "line %d", 7)
+	b.Group("empty")
+	sub := NewBox()
+	sub.PushIf(fmt.Errorf("missing key"), "")
+	b.AddGroup("config.yaml", sub)
+
+	if b.Len() != 3 {
+		t.Errorf("expected 3 entries (empty group omitted), got %d", b.Len())
+	}
+	want := "# 2\ndata.csv:\n    Got 2 errors:\n    ----------------------------\n    # 1\n    bad value\n     +--> line 3\n"
+	if s := b.Error(); !strings.Contains(s, want) || strings.Contains(s, "empty") || !strings.Contains(s, "config.yaml:\n    missing key") {
+		t.Errorf("unexpected rendering of groups: %s", s)
+	}
+}
+
+func TestEmptyGroup(t *testing.T) {
+	b := NewBox()
+	b.Group("empty")
+	called := false
+	b.Then(func() error { called = true; return fmt.Errorf("then") })
+	if !called || b.First() == nil || Cause(b.First()).Error() != "then" {
+		t.Errorf("expected the box with an empty group to be empty, got %v", b.First())
+	}
+
+	b = NewBox()
+	b.Group("empty")
+	b.Group("data").PushIf(fmt.Errorf("bad value"), "")
+	if Cause(b).Error() != "bad value" || Cause(b.First()).Error() != "bad value" {
+		t.Errorf("expected the cause of the first group, got %v", Cause(b))
+	}
+	b.Group("last")
+	if b.Last() != b.First() {
+		t.Errorf("expected the empty group not to be the last error")
+	}
+
+	visit := func(err error) {
+		if Cause(err) == nil {
+			t.Errorf("expected callbacks not to see empty groups")
+		}
+	}
+	b.Filter(func(err error) bool { visit(err); return true })
+	b.Partition(func(err error) bool { visit(err); return true })
+	b.Sort(func(x, y error) bool { visit(x); visit(y); return false })
+	b.PushIf(fmt.Errorf("other"), "")
+	b.Sort(func(x, y error) bool { visit(x); visit(y); return false })
+	if n := b.RemoveIf(func(err error) bool { visit(err); return false }); n != 0 || b.Len() != 2 {
+		t.Errorf("expected nothing to be removed, got %d", n)
+	}
+	b.Group("last").PushIf(fmt.Errorf("late"), "")
+	if b.Len() != 3 {
+		t.Errorf("expected the empty group to be kept, got %d errors", b.Len())
+	}
+}
+
+func TestOnPush(t *testing.T) {
+	var got []string
+	b := NewBoxWithCap(1)
+	b.OnPush(func(err *StackErr) { got = append(got, Cause(err).Error()) })
+
+	b.PushIf(fmt.Errorf("one"), "")
+	Append(b, fmt.Errorf("two"))
+	b.Group("sub") // groups are not errors
+	if len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("expected both errors to be forwarded, got %v", got)
+	}
+
+	// SetHook replaces its own entry only
+	var first, second int
+	b = NewBox()
+	b.OnPush(func(err *StackErr) { got = append(got, Cause(err).Error()) })
+	b.SetHook(func(err *StackErr) { first++ })
+	b.PushIf(fmt.Errorf("three"), "")
+	b.SetHook(func(err *StackErr) { second++ })
+	Append(b, fmt.Errorf("four"))
+	b.Merge(Append(nil, fmt.Errorf("five")).(*Box))
+	b.SetHook(nil)
+	b.PushIf(fmt.Errorf("six"), "")
+	if first != 1 || second != 2 || len(got) != 6 {
+		t.Errorf("expected the hook to be replaced, got %d and %d calls, and %v", first, second, got)
+	}
+}
+
+func TestIsInsideNested(t *testing.T) {
+	target := errors.New("target")
+	inner := NewBox()
+	inner.PushIf(target, "")
+
+	outer := NewBox()
+	outer.PushIf(fmt.Errorf("other"), "")
+	outer.PushIf(fmt.Errorf("batch: %w", inner), "")
+	if !IsInside(outer, target) {
+		t.Errorf("expected the target in a wrapped box")
+	}
+	if !IsInside(WithStack(inner), target) {
+		t.Errorf("expected the target in a box wrapped by WithStack")
+	}
+	deep := errors.New("deep")
+	outer.Group("nested").PushIf(deep, "")
+	if !IsInside(outer, deep) || IsInside(outer, errors.New("deep")) {
+		t.Errorf("unexpected result of IsInside")
+	}
+}
+
+func TestBoxIsAs(t *testing.T) {
+	target := errors.New("target")
+	box := NewBox()
+	box.PushIf(errors.New("other"), "")
+	box.Group("files").PushIf(&os.PathError{Op: "open", Path: "data.csv", Err: target}, "")
+
+	for _, err := range []error{box, WithStack(box), fmt.Errorf("batch: %w", box)} {
+		if errors.Is(err, target) != IsInside(err, target) || !errors.Is(err, target) {
+			t.Errorf("expected errors.Is to find the target in %T", err)
+		}
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) || pathErr.Path != "data.csv" {
+			t.Errorf("expected errors.As to find the path error in %T", err)
+		}
+	}
+	if errors.Is(box, io.EOF) || IsInside(box, io.EOF) {
+		t.Errorf("unexpected match")
+	}
+}
+
+func TestIsInsideAnyAll(t *testing.T) {
+	errA, errB, errC := errors.New("a"), errors.New("b"), errors.New("c")
+	box := Append(errA, errB)
+
+	if !IsInsideAny(box, errC, errB) || IsInsideAny(box, errC) {
+		t.Errorf("unexpected result of IsInsideAny")
+	}
+	if !IsInsideAll(box, errA, errB) || IsInsideAll(box, errA, errC) {
+		t.Errorf("unexpected result of IsInsideAll")
+	}
+}
+
+func TestCount(t *testing.T) {
+	notFound := errors.New("not found")
+	b := NewBox()
+	for i := 0; i < 5; i++ {
+		if i%2 == 0 {
+			b.PushIf(fmt.Errorf("item %d: %w", i, notFound), "")
+		} else {
+			b.PushIf(fmt.Errorf("item %d: broken", i), "")
+		}
+	}
+	if n := b.Count(notFound); n != 3 {
+		t.Errorf("expected 3 errors, got %d", n)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	retryable := errors.New("retryable")
+	b := NewBox()
+	b.PushIf(retryable, "")
+	b.PushIf(fmt.Errorf("fatal"), "")
+	b.PushIf(fmt.Errorf("again: %w", retryable), "")
+
+	matched, rest := b.Partition(func(err error) bool { return errors.Is(err, retryable) })
+	if matched.Len() != 2 || rest.Len() != 1 || b.Len() != 3 {
+		t.Errorf("unexpected partition: %d, %d, %d", matched.Len(), rest.Len(), b.Len())
+	}
+}
+
+type failingCloser struct{ err error }
+
+func (c failingCloser) Close() error { return c.err }
+
+func TestCloseAndAppend(t *testing.T) {
+	closeErr, workErr := errors.New("close failed"), errors.New("work failed")
+	work := func(closer io.Closer, fail bool) (err error) {
+		defer CloseAndAppend(&err, closer, "closing")
+		if fail {
+			return workErr
+		}
+		return nil
+	}
+
+	if err := work(failingCloser{}, false); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := work(failingCloser{closeErr}, false); !errors.Is(err, closeErr) || !strings.Contains(err.Error(), "> closing") {
+		t.Errorf("expected annotated close error, got %v", err)
+	}
+	err := work(failingCloser{closeErr}, true)
+	if !IsInsideAll(err, closeErr, workErr) {
+		t.Errorf("expected both errors, got %v", err)
+	}
+}
+
+func TestNilReceivers(t *testing.T) {
+	var box *Box
+	var err error = box
+	if box.Error() != "" || box.Len() != 0 || !box.IsEmpty() || box.First() != nil || box.Last() != nil {
+		t.Errorf("expected a nil box to be empty")
+	}
+	if Errors(err) != nil {
+		t.Errorf("expected no errors in a nil box")
+	}
+
+	var pathErr *os.PathError
+	if WithStack(pathErr) != nil || Annotate(pathErr, "reading") != nil || Cause(pathErr) != nil {
+		t.Errorf("expected a typed nil to be treated as nil")
+	}
+	if Append(nil, pathErr) != nil || Append(err, nil) != err {
+		t.Errorf("expected a typed nil not to be appended")
+	}
+	if box := NewBox(); box.PushIf(pathErr, "") || !box.IsEmpty() {
+		t.Errorf("expected a typed nil not to be pushed")
+	}
+}
+
+func TestTypedNil(t *testing.T) {
+	target := errors.New("target")
+	helpers := map[string]func(err error){
+		"WithCode":               func(err error) { _ = WithCode(err, "E1") },
+		"CodeOf":                 func(err error) { _ = CodeOf(err) },
+		"Append":                 func(err error) { _ = Append(err, target) },
+		"AppendNested":           func(err error) { _ = AppendNested(nil, err, "group") },
+		"Errors":                 func(err error) { _ = Errors(err) },
+		"IsInside":               func(err error) { _ = IsInside(err, target) },
+		"IsInsideAny":            func(err error) { _ = IsInsideAny(err, target) },
+		"IsInsideAll":            func(err error) { _ = IsInsideAll(err, target) },
+		"ExitCode":               func(err error) { _ = ExitCode(err) },
+		"WithField":              func(err error) { _ = WithField(err, "k", 1) },
+		"WithFields":             func(err error) { _ = WithFields(err, map[string]interface{}{"k": 1}) },
+		"Field":                  func(err error) { _, _ = Field[int](err, "k") },
+		"FieldOf":                func(err error) { _, _ = FieldOf(err, "k") },
+		"StringFieldOf":          func(err error) { _ = StringFieldOf(err, "k") },
+		"HTTPStatus":             func(err error) { _ = HTTPStatus(err) },
+		"WithKind":               func(err error) { _ = WithKind(err, KindInvalid) },
+		"KindOf":                 func(err error) { _ = KindOf(err) },
+		"LogrusFields":           func(err error) { _ = LogrusFields(err) },
+		"WithUserMessage":        func(err error) { _ = WithUserMessage(err, "oops") },
+		"UserMessage":            func(err error) { _ = UserMessage(err) },
+		"Hint":                   func(err error) { _ = Hint(err, "retry later") },
+		"Hints":                  func(err error) { _ = Hints(err) },
+		"MarkRetryable":          func(err error) { _ = MarkRetryable(err) },
+		"Permanent":              func(err error) { _ = Permanent(err) },
+		"IsRetryable":            func(err error) { _ = IsRetryable(err) },
+		"IsTimeout":              func(err error) { _ = IsTimeout(err) },
+		"WithSeverity":           func(err error) { _ = WithSeverity(err, SeverityWarning) },
+		"SeverityOf":             func(err error) { _ = SeverityOf(err) },
+		"SlogAttrs":              func(err error) { _ = SlogAttrs(err) },
+		"Annotate":               func(err error) { _ = Annotate(err, "msg") },
+		"AnnotateIf":             func(err error) { _ = AnnotateIf(true, err, "msg") },
+		"AnnotateUnless":         func(err error) { _ = AnnotateUnless(err, target, "msg") },
+		"AnnotateLazy":           func(err error) { _ = AnnotateLazy(err, "msg") },
+		"DeferAnnotate":          func(err error) { DeferAnnotate(&err, "msg") },
+		"WithStack":              func(err error) { _ = WithStack(err) },
+		"Restore":                func(err error) { _ = Restore(err, "msg") },
+		"Fingerprint":            func(err error) { _ = Fingerprint(err) },
+		"Cause":                  func(err error) { _ = Cause(err) },
+		"RootCause":              func(err error) { _ = RootCause(err) },
+		"Tree":                   func(err error) { _ = Tree(err) },
+		"Walk":                   func(err error) { Walk(err, func(error) bool { return true }) },
+		"Find":                   func(err error) { _, _ = Find[*os.PathError](err) },
+		"Origin.Attach":          func(err error) { _ = captureOrigin(2).Attach(err) },
+		"Box.PushIf":             func(err error) { NewBox().PushIf(err, "") },
+		"Box.PushIfErr":          func(err error) { _ = NewBox().PushIfErr(err, "") },
+		"Box.PushIfLazy":         func(err error) { NewBox().PushIfLazy(err, "") },
+		"Box.PushTagged":         func(err error) { NewBox().PushTagged(err, nil, "") },
+		"ShardedBox.PushIf":      func(err error) { NewShardedBox(2).PushIf(err, "") },
+		"StackErr.AddSuppressed": func(err error) { WithStack(target).AddSuppressed(err) },
+	}
+	nils := map[string]error{
+		"*os.PathError": (*os.PathError)(nil),
+		"*Box":          (*Box)(nil),
+		"*StackErr":     (*StackErr)(nil),
+	}
+	for name, helper := range helpers {
+		for typ, err := range nils {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("%s panicked with a nil %s: %v", name, typ, r)
+					}
+				}()
+				helper(err)
+			}()
+		}
+	}
+}
+
+func TestSelfAppend(t *testing.T) {
+	box := NewBox()
+	box.PushIf(fmt.Errorf("boom"), "")
+	if Append(box, box) != box || box.PushIf(box, "") || box.PushIf(fmt.Errorf("wrapped: %w", box), "") {
+		t.Errorf("expected the box not to be pushed into itself")
+	}
+	box.Merge(box)
+	box.AddGroup("self", box)
+	AppendNested(box, box, "self")
+
+	sub := box.Group("sub")
+	sub.PushIf(box, "")
+	sub.AddGroup("parent", box)
+	_ = Append(sub, box)
+	if box.Len() != 1 || sub.Len() != 0 {
+		t.Errorf("expected no cycle, got:\n%s", box)
+	}
+
+	err := WithStack(fmt.Errorf("boom"))
+	err.AddSuppressed(err)
+	if len(err.Suppressed()) != 0 {
+		t.Errorf("expected the error not to suppress itself")
+	}
+}
+
+func TestAnnotateGroups(t *testing.T) {
+	b := NewBox()
+	b.Group("data.csv").PushIf(fmt.Errorf("bad value"), "")
+	Annotate(b, "outer annotation")
+	AnnotateLazy(b, "lazy %s", "annotation")
+	WithFields(b, map[string]interface{}{"file": "data.csv"})
+	if s := b.Error(); !strings.Contains(s, "outer annotation") || !strings.Contains(s, "lazy annotation") {
+		t.Errorf("expected annotations of errors in groups:\n%s", s)
+	}
+	if v, _ := FieldOf(b.Group("data.csv"), "file"); v != "data.csv" {
+		t.Errorf("expected the field on errors in groups, got %v", v)
+	}
+}
@@ -0,0 +1,40 @@
+package errboxgrpc
+
+import (
+	"context"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor, which recovers panics in handlers (see errbox.Recover),
+// annotates errors returned by handlers with the full method name, and converts them to gRPC statuses with
+// details (see ToStatus). Errors which already are plain gRPC statuses are returned unchanged.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() { err = convert(err, info.FullMethod) }()
+		defer errbox.Recover(&err)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor for streams, see UnaryServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() { err = convert(err, info.FullMethod) }()
+		defer errbox.Recover(&err)
+		return handler(srv, ss)
+	}
+}
+
+// convert implements the interceptors.
+func convert(err error, method string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return err
+	}
+	return ToStatus(errbox.Annotate(err, "%s", method)).Err()
+}
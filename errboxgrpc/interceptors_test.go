@@ -0,0 +1,58 @@
+package errboxgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Get"}
+	call := func(handler grpc.UnaryHandler) error {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		return err
+	}
+
+	err := call(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errbox.NotFound("user %d", 42)
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+	errbox.ShowStack(false)
+	defer errbox.ShowStack(true)
+	if s := FromError(err).Error(); !strings.Contains(s, "/users.Users/Get") {
+		t.Errorf("expected the method in annotations:\n%s", s)
+	}
+
+	err = call(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if st := status.Convert(err); st.Code() != codes.Unknown || st.Message() != "panic: boom" {
+		t.Errorf("expected the panic to be converted, got %v", err)
+	}
+
+	plain := status.Error(codes.PermissionDenied, "nope")
+	if err := call(func(ctx context.Context, req interface{}) (interface{}, error) { return nil, plain }); err != plain {
+		t.Errorf("expected plain status to be unchanged, got %v", err)
+	}
+	if err := call(func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	err := StreamServerInterceptor()(nil, nil, &grpc.StreamServerInfo{FullMethod: "/users.Users/List"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			return errbox.Unavailable("database")
+		})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected unavailable, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Package errboxgrpc converts errors from the errbox package to gRPC statuses and back, keeping their structure
+(code, kind, annotations and fields) as status details, so that the client can reconstruct a StackErr.
+
+It lives in a separate module, so that the errbox package itself does not depend on gRPC.
+*/
+package errboxgrpc
+
+import (
+	"fmt"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the domain of errdetails.ErrorInfo attached to statuses by ToStatus.
+const Domain = "errbox"
+
+// Detail of errdetails.DebugInfo attached to statuses by ToStatus.
+const (
+	detailAnnotations = "errbox.annotations"
+	detailFrames      = "errbox.frames"
+)
+
+// kindCodes maps kinds of errors to gRPC codes, and back.
+var kindCodes = map[errbox.Kind]codes.Code{
+	errbox.KindUnknown:          codes.Unknown,
+	errbox.KindNotFound:         codes.NotFound,
+	errbox.KindInvalid:          codes.InvalidArgument,
+	errbox.KindAlreadyExists:    codes.AlreadyExists,
+	errbox.KindPermissionDenied: codes.PermissionDenied,
+	errbox.KindUnauthenticated:  codes.Unauthenticated,
+	errbox.KindUnavailable:      codes.Unavailable,
+	errbox.KindInternal:         codes.Internal,
+}
+
+// ToStatus converts the err to a gRPC status. The gRPC code is derived from the kind of the err
+// (see errbox.KindOf); errors without a kind keep the code of a gRPC status they wrap, if any (for example,
+// an error received from another service), or of a context error.
+//
+// The message of the status is the cause of the err (see errbox.Cause), and details of the status are:
+//
+//   - errdetails.ErrorInfo with the Domain, the code of the err (see errbox.CodeOf) as the reason,
+//     and fields of the err formatted by fmt.Sprint as the metadata,
+//   - errdetails.DebugInfo with annotation messages, and another one with stack frames (if the stack trace
+//     would be printed out, see errbox.ShowStack).
+//
+// When the err is a *errbox.Box, the status describes its first error. Nil is returned if the err is nil.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	if b, ok := err.(*errbox.Box); ok {
+		errs := errbox.Errors(b)
+		if len(errs) == 0 {
+			return nil
+		}
+		err = errs[0]
+	}
+
+	code, ok := kindCodes[errbox.KindOf(err)]
+	if !ok {
+		code = codes.Unknown // kinds returned by classifiers, which have no gRPC counterpart
+	}
+	if code == codes.Unknown {
+		if st, ok := status.FromError(err); ok {
+			code = st.Code()
+		} else if ctxErr := status.FromContextError(err); ctxErr.Code() != codes.Unknown {
+			code = ctxErr.Code()
+		}
+	}
+	message := errbox.Cause(err).Error()
+	if cause, ok := status.FromError(errbox.Cause(err)); ok {
+		message = cause.Message()
+	}
+	st := status.New(code, message)
+
+	info := &errdetails.ErrorInfo{Reason: errbox.CodeOf(err), Domain: Domain}
+	for k, v := range errbox.Inspect(err).CopyFields() {
+		if info.Metadata == nil {
+			info.Metadata = make(map[string]string)
+		}
+		info.Metadata[k] = fmt.Sprint(v)
+	}
+	details := []protoadapt.MessageV1{protoadapt.MessageV1Of(info)}
+	for _, attr := range errbox.SlogAttrs(err) {
+		var detail string
+		switch attr.Key {
+		case "annotations":
+			detail = detailAnnotations
+		case "frames":
+			detail = detailFrames
+		default:
+			continue
+		}
+		if entries, ok := attr.Value.Resolve().Any().([]string); ok {
+			details = append(details, protoadapt.MessageV1Of(&errdetails.DebugInfo{StackEntries: entries, Detail: detail}))
+		}
+	}
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		st = withDetails
+	}
+	return st
+}
+
+// FromStatus converts the gRPC status back to an error, typically on the client side. If the status was created
+// by ToStatus, the error is a *errbox.StackErr with the kind, code, annotation messages and fields
+// (as strings) of the original error. The cause of the error carries the status, so that status.FromError
+// and status.Code work with the error.
+//
+// Nil is returned if the st is nil, or if its code is codes.OK.
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	var annotations []string
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() == Domain {
+				info = d
+			}
+		case *errdetails.DebugInfo:
+			if d.GetDetail() == detailAnnotations {
+				annotations = d.GetStackEntries()
+			}
+		}
+	}
+
+	err := errbox.Restore(&statusError{st: st}, annotations...)
+	for kind, code := range kindCodes {
+		if code == st.Code() && kind != errbox.KindUnknown {
+			errbox.WithKind(err, kind)
+		}
+	}
+	if info != nil {
+		if reason := info.GetReason(); reason != "" {
+			errbox.RestoreCode(err, reason)
+		}
+		for k, v := range info.GetMetadata() {
+			err.SetField(k, v)
+		}
+	}
+	return err
+}
+
+// FromError converts the err returned by a gRPC call by FromStatus, see status.Convert.
+// Nil is returned if the err is nil.
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return FromStatus(status.Convert(err))
+}
+
+// statusError is the cause of errors returned by FromStatus.
+type statusError struct {
+	st *status.Status
+}
+
+// Error returns the message of the status.
+func (e *statusError) Error() string {
+	return e.st.Message()
+}
+
+// GRPCStatus returns the status, see status.FromError.
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.st
+}
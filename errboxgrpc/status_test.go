@@ -0,0 +1,69 @@
+package errboxgrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoundTrip(t *testing.T) {
+	err := errbox.Annotate(errbox.NotFound("user %d", 42), "loading profile")
+	err = errbox.WithField(errbox.WithCode(err, "USER_NOT_FOUND"), "user_id", 42)
+
+	st := ToStatus(err)
+	if st.Code() != codes.NotFound || st.Message() != "user 42" {
+		t.Fatalf("unexpected status: %v", st)
+	}
+
+	got := FromError(st.Err())
+	if errbox.KindOf(got) != errbox.KindNotFound {
+		t.Errorf("expected not found, got %v", errbox.KindOf(got))
+	}
+	if errbox.CodeOf(got) != "USER_NOT_FOUND" {
+		t.Errorf("unexpected code %q", errbox.CodeOf(got))
+	}
+	if errbox.StringFieldOf(got, "user_id") != "42" {
+		t.Errorf("unexpected fields: %v", errbox.WithStack(got).CopyFields())
+	}
+	if status.Code(got) != codes.NotFound {
+		t.Errorf("expected the status to be kept, got %v", status.Code(got))
+	}
+	errbox.ShowStack(false)
+	defer errbox.ShowStack(true)
+	if s := got.Error(); s != "user 42\n +--> loading profile\n code: USER_NOT_FOUND\n" {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	if ToStatus(nil) != nil || FromStatus(nil) != nil || FromStatus(status.New(codes.OK, "")) != nil {
+		t.Errorf("expected nil")
+	}
+	if c := ToStatus(errbox.Annotate(context.DeadlineExceeded, "calling")).Code(); c != codes.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", c)
+	}
+	if c := ToStatus(errors.New("boom")).Code(); c != codes.Unknown {
+		t.Errorf("expected unknown, got %v", c)
+	}
+	if st := ToStatus(errbox.WithKind(errors.New("boom"), errbox.Kind(99))); st.Code() != codes.Unknown || st.Err() == nil {
+		t.Errorf("expected an unmapped kind to be unknown, got %v", st.Code())
+	}
+}
+
+func TestFromStatusStrictCodes(t *testing.T) {
+	st := ToStatus(errbox.RestoreCode(errbox.NotFound("user"), "REMOTE_ONLY"))
+
+	opts := errbox.CurrentOptions()
+	defer errbox.Configure(opts)
+	strict := opts
+	strict.StrictCodes = true
+	errbox.Configure(strict)
+
+	if code := errbox.CodeOf(FromStatus(st)); code != "REMOTE_ONLY" {
+		t.Errorf("expected the remote code, got %q", code)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Package errboxhttp adapts handlers which return errors to net/http. Failed requests get a status derived
+from the error (see errbox.HTTPStatus), an RFC 7807 problem details body, and the full annotated error is logged.
+*/
+package errboxhttp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/jan-herout/errbox"
+)
+
+// HandlerFunc is a HTTP handler which returns an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Problem is the RFC 7807 problem details body written by Handler.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`   // user message of the error, see errbox.UserMessage
+	Instance string `json:"instance,omitempty"` // path of the request
+	Code     string `json:"code,omitempty"`     // code of the error, see errbox.CodeOf
+}
+
+// Option configures Handler.
+type Option func(h *handler)
+
+// WithLogger sets the logger used for failed requests, slog.Default is used by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *handler) { h.logger = logger }
+}
+
+// Handler returns a http.Handler which calls the fn, and handles the error it returns:
+//
+//   - panics in the fn are recovered, and converted to errors (see errbox.Recover),
+//   - the status is derived from the error (see errbox.HTTPStatus),
+//   - the body is a Problem, encoded as "application/problem+json"; only the user message of the error
+//     (see errbox.UserMessage) is sent to the client, never the annotations,
+//   - the full annotated error is logged, at the error level for server errors, and at the warning level
+//     for client errors.
+//
+// If the fn already wrote the response, the error is only logged.
+func Handler(fn HandlerFunc, opts ...Option) http.Handler {
+	h := &handler{fn: fn}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// handler implements Handler.
+type handler struct {
+	fn     HandlerFunc
+	logger *slog.Logger
+}
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &responseWriter{ResponseWriter: w}
+	err := h.serve(rw, r)
+	if err == nil {
+		return
+	}
+
+	status := errbox.HTTPStatus(err)
+	level := slog.LevelError
+	if status < http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	logger := h.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if _, ok := err.(*errbox.Box); !ok {
+		err = errbox.Inspect(err)
+	}
+	logger.Log(r.Context(), level, "request failed", "method", r.Method, "path", r.URL.Path, "status", status, "error", err)
+
+	if rw.written {
+		return
+	}
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   errbox.UserMessage(err),
+		Instance: r.URL.Path,
+		Code:     errbox.CodeOf(err),
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// serve calls the fn, and converts panics to errors.
+func (h *handler) serve(w http.ResponseWriter, r *http.Request) (err error) {
+	defer errbox.Recover(&err)
+	return h.fn(w, r)
+}
+
+// responseWriter remembers if the response was already written.
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original http.ResponseWriter, see http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
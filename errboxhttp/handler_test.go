@@ -0,0 +1,55 @@
+package errboxhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+)
+
+func TestHandler(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	h := Handler(func(w http.ResponseWriter, r *http.Request) error {
+		err := errbox.WithCode(errbox.NotFound("user %s", r.URL.Query().Get("id")), "USER_NOT_FOUND")
+		return errbox.WithUserMessage(errbox.Annotate(err, "loading user"), "No such user")
+	}, WithLogger(logger))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?id=42", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "No such user", Instance: "/users", Code: "USER_NOT_FOUND"}
+	if p != want {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if s := logs.String(); !strings.Contains(s, `"level":"WARN"`) || !strings.Contains(s, "loading user") {
+		t.Errorf("expected the annotated error to be logged: %s", s)
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	var logs bytes.Buffer
+	h := Handler(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}, WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(logs.String(), "panic: boom") {
+		t.Errorf("expected the panic to be logged: %s", logs.String())
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Package errboxotel records errors from the errbox package on OpenTelemetry spans, keeping their structure
+(cause, annotations and fields), so that traces carry the same information as logs.
+
+It lives in a separate module, so that the errbox package itself does not depend on OpenTelemetry.
+*/
+package errboxotel
+
+import (
+	"fmt"
+
+	"github.com/jan-herout/errbox"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys used by RecordSpanError.
+const (
+	KeyCode   = attribute.Key("errbox.code")
+	KeyKind   = attribute.Key("errbox.kind")
+	KeyFrames = attribute.Key("errbox.frames")
+)
+
+// RecordSpanError records the err on the span:
+//
+//   - the cause of the err (see errbox.Cause) is recorded as the span error (see trace.Span.RecordError),
+//     with the code, the kind and stack frames (if the stack trace would be printed out) as attributes,
+//   - annotation messages are added as span events, oldest first,
+//   - fields of the err are set as span attributes,
+//   - the status of the span is set to codes.Error.
+//
+// When the err is a *errbox.Box, every error in the box is recorded. Nothing happens if the err is nil.
+func RecordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	if b, ok := err.(*errbox.Box); ok {
+		errs := errbox.Errors(b)
+		if len(errs) == 0 {
+			return
+		}
+		for _, e := range errs {
+			record(span, e)
+		}
+	} else {
+		record(span, err)
+	}
+	span.SetStatus(codes.Error, errbox.Cause(err).Error())
+}
+
+// record records a single error, see RecordSpanError.
+func record(span trace.Span, err error) {
+	var attrs []attribute.KeyValue
+	if code := errbox.CodeOf(err); code != "" {
+		attrs = append(attrs, KeyCode.String(code))
+	}
+	if kind := errbox.KindOf(err); kind != errbox.KindUnknown {
+		attrs = append(attrs, KeyKind.String(kind.String()))
+	}
+
+	var annotations []string
+	for _, attr := range errbox.SlogAttrs(err) {
+		entries, ok := attr.Value.Resolve().Any().([]string)
+		if !ok {
+			continue
+		}
+		switch attr.Key {
+		case "annotations":
+			annotations = entries
+		case "frames":
+			attrs = append(attrs, KeyFrames.StringSlice(entries))
+		}
+	}
+
+	span.RecordError(errbox.Cause(err), trace.WithAttributes(attrs...))
+	for _, message := range annotations {
+		span.AddEvent(message)
+	}
+	for k, v := range errbox.Inspect(err).CopyFields() {
+		span.SetAttributes(fieldAttr(k, v))
+	}
+}
+
+// fieldAttr converts the field to an attribute; values of unsupported types are formatted by fmt.Sprint.
+func fieldAttr(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
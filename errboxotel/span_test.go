@@ -0,0 +1,56 @@
+package errboxotel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecordSpanError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer("test").Start(context.Background(), "checkout")
+
+	err := errbox.Annotate(fmt.Errorf("card declined"), "charging card")
+	err = errbox.WithField(errbox.Annotate(errbox.WithCode(err, "CARD_DECLINED"), "processing order"), "order_id", 42)
+	RecordSpanError(span, err)
+	RecordSpanError(span, nil)
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Status().Code != codes.Error || s.Status().Description != "card declined" {
+		t.Errorf("unexpected status: %v", s.Status())
+	}
+	var names []string
+	for _, e := range s.Events() {
+		names = append(names, e.Name)
+	}
+	if fmt.Sprint(names) != "[exception charging card processing order]" {
+		t.Errorf("unexpected events: %v", names)
+	}
+	if attrs := s.Events()[0].Attributes; !contains(attrs, KeyCode.String("CARD_DECLINED")) {
+		t.Errorf("expected code on the exception: %v", attrs)
+	}
+	if !contains(s.Attributes(), attribute.Int("order_id", 42)) {
+		t.Errorf("expected field as attribute: %v", s.Attributes())
+	}
+}
+
+func contains(attrs []attribute.KeyValue, kv attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == kv {
+			return true
+		}
+	}
+	return false
+}
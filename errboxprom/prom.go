@@ -0,0 +1,103 @@
+/*
+Package errboxprom counts errors from the errbox package with Prometheus, so that services using errbox
+get error-rate metrics for free. Errors are counted when they are created (annotated for the first time),
+and when they are pushed into a box, see errbox.Observer.
+
+	func main() {
+		errboxprom.MustInstall(prometheus.DefaultRegisterer)
+		// ...
+	}
+
+It lives in a separate module, so that the errbox package itself does not depend on Prometheus.
+*/
+package errboxprom
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/jan-herout/errbox"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Labels of the counter.
+const (
+	LabelEvent   = "event"   // "created", or "pushed"
+	LabelCode    = "code"    // code of the error, see errbox.CodeOf
+	LabelKind    = "kind"    // kind of the error, see errbox.KindOf
+	LabelPackage = "package" // package where the error was annotated for the first time
+)
+
+// Observer counts errors, it implements errbox.Observer.
+type Observer struct {
+	errors *prometheus.CounterVec
+}
+
+// NewObserver returns a new observer, and registers its counter "errbox_errors_total" by the reg.
+// Use errbox.SetObserver to start counting errors, or use Install.
+func NewObserver(reg prometheus.Registerer) (*Observer, error) {
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "errbox_errors_total",
+		Help: "Number of errors created or pushed into a box.",
+	}, []string{LabelEvent, LabelCode, LabelKind, LabelPackage})
+	if err := reg.Register(errors); err != nil {
+		return nil, err
+	}
+	return &Observer{errors: errors}, nil
+}
+
+// Install creates a new observer (see NewObserver), and starts counting errors by it (see errbox.SetObserver).
+func Install(reg prometheus.Registerer) error {
+	o, err := NewObserver(reg)
+	if err != nil {
+		return err
+	}
+	errbox.SetObserver(o)
+	return nil
+}
+
+// MustInstall works like Install, but it panics if the counter can not be registered.
+func MustInstall(reg prometheus.Registerer) {
+	if err := Install(reg); err != nil {
+		panic(err)
+	}
+}
+
+// ErrorCreated implements errbox.Observer.
+func (o *Observer) ErrorCreated(err *errbox.StackErr) {
+	o.count("created", err)
+}
+
+// ErrorPushed implements errbox.Observer.
+func (o *Observer) ErrorPushed(err *errbox.StackErr) {
+	o.count("pushed", err)
+}
+
+// count increments the counter for the error.
+func (o *Observer) count(event string, err *errbox.StackErr) {
+	kind := ""
+	if k := errbox.KindOf(err); k != errbox.KindUnknown {
+		kind = k.String()
+	}
+	o.errors.WithLabelValues(event, errbox.CodeOf(err), kind, callerPackage(err)).Inc()
+}
+
+// callerPackage returns the package where the error was annotated for the first time, or empty string.
+func callerPackage(err *errbox.StackErr) string {
+	pcs := err.Callers()
+	if len(pcs) == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames(pcs[:1]).Next()
+	return packageName(frame.Function)
+}
+
+// packageName returns the package path of the fully qualified function name, like
+// "github.com/jan-herout/errbox" for "github.com/jan-herout/errbox.(*Box).PushIf".
+func packageName(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
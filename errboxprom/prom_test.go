@@ -0,0 +1,47 @@
+package errboxprom
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserver(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	o, err := NewObserver(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errbox.SetObserver(o)
+	defer errbox.SetObserver(nil)
+
+	box := errbox.NewBox()
+	box.PushIf(errbox.NotFound("user %d", 42), "loading user")
+	box.PushIf(errors.New("boom"), "")
+
+	pkg := "github.com/jan-herout/errbox/errboxprom"
+	if n := testutil.ToFloat64(o.errors.WithLabelValues("created", "", "not found", pkg)); n != 1 {
+		t.Errorf("expected one created error, got %v", n)
+	}
+	if n := testutil.ToFloat64(o.errors.WithLabelValues("pushed", "", "", pkg)); n != 1 {
+		t.Errorf("expected one pushed uncategorized error, got %v", n)
+	}
+	if _, err := NewObserver(reg); err == nil {
+		t.Errorf("expected error on duplicate registration")
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for function, want := range map[string]string{
+		"github.com/jan-herout/errbox.(*Box).PushIf": "github.com/jan-herout/errbox",
+		"main.main":                                 "main",
+		"github.com/a/b.c/d.Func":                   "github.com/a/b.c/d",
+	} {
+		if got := packageName(function); got != want {
+			t.Errorf("%s: expected %q, got %q", function, want, got)
+		}
+	}
+}
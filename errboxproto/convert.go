@@ -0,0 +1,145 @@
+/*
+Package errboxproto converts errors from the errbox package to protocol buffers and back, so that errors
+can travel through protobuf based channels without being flattened to a string. The schema is defined
+in errbox.proto; it follows errbox.Record, which is also the schema of the JSON encoding of errors.
+
+It lives in a separate module, so that the errbox package itself does not depend on protobuf.
+*/
+package errboxproto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative errbox.proto
+
+import (
+	"fmt"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ToProto converts the err to the protobuf message. Errors which are neither *errbox.StackErr,
+// nor *errbox.Box are converted by errbox.Inspect first. Nil is returned if the err is nil.
+//
+// If a field (of the err, or of any error in it) can not be represented by structpb.Value, the conversion fails,
+// and the second return value says which field it was.
+func ToProto(err error) (*Error, error) {
+	r := errbox.ToRecord(err)
+	if r == nil {
+		return nil, nil
+	}
+	return toProto(r)
+}
+
+// FromProto reconstructs the error from the protobuf message, see errbox.FromRecord.
+// Nil is returned if the msg is nil.
+func FromProto(msg *Error) (error, error) {
+	if msg == nil {
+		return nil, nil
+	}
+	return errbox.FromRecord(fromProto(msg)), nil
+}
+
+// toProto converts the record to the message.
+func toProto(r *errbox.Record) (*Error, error) {
+	msg := &Error{
+		Code:        r.Code,
+		Kind:        r.Kind,
+		Severity:    r.Severity,
+		Retryable:   r.Retryable,
+		UserMessage: r.UserMessage,
+		Hints:       r.Hints,
+		Tags:        r.Tags,
+		Group:       r.Group,
+		Service:     r.Service,
+	}
+	var err error
+	if r.Remote != nil {
+		if msg.Remote, err = toProto(r.Remote); err != nil {
+			return nil, err
+		}
+	}
+	if r.Cause != nil {
+		msg.Cause = *r.Cause
+	} else {
+		msg.Box = &Box{Dropped: int32(r.Dropped)}
+		for _, e := range r.Errors {
+			inner, err := toProto(e)
+			if err != nil {
+				return nil, err
+			}
+			msg.Box.Errors = append(msg.Box.Errors, inner)
+		}
+	}
+	for _, a := range r.Annotations {
+		msg.Annotations = append(msg.Annotations, &Annotation{
+			Message:  a.Message,
+			File:     a.File,
+			Line:     int32(a.Line),
+			Function: a.Function,
+			Repeated: int32(a.Repeated),
+		})
+	}
+	for k, v := range r.Fields {
+		value, err := structpb.NewValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("errboxproto: field %q: %w", k, err)
+		}
+		if msg.Fields == nil {
+			msg.Fields = make(map[string]*structpb.Value)
+		}
+		msg.Fields[k] = value
+	}
+	for _, s := range r.Suppressed {
+		suppressed, err := toProto(s)
+		if err != nil {
+			return nil, err
+		}
+		msg.Suppressed = append(msg.Suppressed, suppressed)
+	}
+	return msg, nil
+}
+
+// fromProto converts the message to the record.
+func fromProto(msg *Error) *errbox.Record {
+	r := &errbox.Record{
+		Code:        msg.GetCode(),
+		Kind:        msg.GetKind(),
+		Severity:    msg.GetSeverity(),
+		Retryable:   msg.Retryable,
+		UserMessage: msg.GetUserMessage(),
+		Hints:       msg.GetHints(),
+		Tags:        msg.GetTags(),
+		Group:       msg.GetGroup(),
+		Service:     msg.GetService(),
+	}
+	if remote := msg.GetRemote(); remote != nil {
+		r.Remote = fromProto(remote)
+	}
+	if box := msg.GetBox(); box != nil {
+		r.Dropped = int(box.GetDropped())
+		for _, e := range box.GetErrors() {
+			r.Errors = append(r.Errors, fromProto(e))
+		}
+	} else {
+		cause := msg.GetCause()
+		r.Cause = &cause
+	}
+	for _, a := range msg.GetAnnotations() {
+		r.Annotations = append(r.Annotations, errbox.Annotation{
+			Message:  a.GetMessage(),
+			File:     a.GetFile(),
+			Line:     int(a.GetLine()),
+			Function: a.GetFunction(),
+			Repeated: int(a.GetRepeated()),
+		})
+	}
+	for k, v := range msg.GetFields() {
+		if r.Fields == nil {
+			r.Fields = make(map[string]interface{})
+		}
+		r.Fields[k] = v.AsInterface()
+	}
+	for _, s := range msg.GetSuppressed() {
+		r.Suppressed = append(r.Suppressed, fromProto(s))
+	}
+	return r
+}
@@ -0,0 +1,77 @@
+package errboxproto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+	err := errbox.WithCode(errbox.NotFound("user %d", 42), "USER_NOT_FOUND")
+	err = errbox.WithField(errbox.Annotate(err, "loading profile"), "user_id", 42)
+	box := errbox.NewBox()
+	box.PushIf(err, "")
+	box.Group("config").PushIf(errors.New("unknown key"), "parsing")
+
+	msg, perr := ToProto(box)
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	data, perr := proto.Marshal(msg)
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	var decoded Error
+	if perr := proto.Unmarshal(data, &decoded); perr != nil {
+		t.Fatal(perr)
+	}
+	got, perr := FromProto(&decoded)
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	if got.Error() != box.Error() {
+		t.Errorf("expected the same rendering, got:\n%s\nwant:\n%s", got, box)
+	}
+	if errbox.CodeOf(got) != "USER_NOT_FOUND" || errbox.KindOf(got) != errbox.KindNotFound {
+		t.Errorf("classification was lost: %v", &decoded)
+	}
+	if id, _ := errbox.Field[float64](got, "user_id"); id != 42 {
+		t.Errorf("field was lost: %v", &decoded)
+	}
+}
+
+func TestNil(t *testing.T) {
+	if msg, err := ToProto(nil); msg != nil || err != nil {
+		t.Errorf("expected nil, got %v, %v", msg, err)
+	}
+	if got, err := FromProto(nil); got != nil || err != nil {
+		t.Errorf("expected nil, got %v, %v", got, err)
+	}
+}
+
+func TestFromProtoStrictCodes(t *testing.T) {
+	msg, err := ToProto(errbox.RestoreCode(errors.New("remote"), "REMOTE_ONLY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := errbox.CurrentOptions()
+	defer errbox.Configure(opts)
+	strict := opts
+	strict.StrictCodes = true
+	errbox.Configure(strict)
+
+	if got, _ := FromProto(msg); errbox.CodeOf(got) != "REMOTE_ONLY" {
+		t.Errorf("expected the remote code, got %v", got)
+	}
+}
+
+func TestToProtoFieldError(t *testing.T) {
+	err := errbox.Append(nil, errbox.WithField(errors.New("x"), "ch", make(chan int)))
+	if msg, perr := ToProto(err); msg != nil || perr == nil || !strings.Contains(perr.Error(), `"ch"`) {
+		t.Errorf("expected the field error, got %v, %v", msg, perr)
+	}
+}
@@ -0,0 +1,412 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: errbox.proto
+
+package errboxproto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Error struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	Cause         string                     `protobuf:"bytes,1,opt,name=cause,proto3" json:"cause,omitempty"`
+	Annotations   []*Annotation              `protobuf:"bytes,2,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	Code          string                     `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	Kind          string                     `protobuf:"bytes,4,opt,name=kind,proto3" json:"kind,omitempty"`
+	Severity      string                     `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	Retryable     *bool                      `protobuf:"varint,6,opt,name=retryable,proto3,oneof" json:"retryable,omitempty"`
+	UserMessage   string                     `protobuf:"bytes,7,opt,name=user_message,json=userMessage,proto3" json:"user_message,omitempty"`
+	Hints         []string                   `protobuf:"bytes,8,rep,name=hints,proto3" json:"hints,omitempty"`
+	Tags          map[string]string          `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Fields        map[string]*structpb.Value `protobuf:"bytes,10,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Suppressed    []*Error                   `protobuf:"bytes,11,rep,name=suppressed,proto3" json:"suppressed,omitempty"`
+	Group         string                     `protobuf:"bytes,12,opt,name=group,proto3" json:"group,omitempty"`
+	Box           *Box                       `protobuf:"bytes,13,opt,name=box,proto3" json:"box,omitempty"`
+	Service       string                     `protobuf:"bytes,14,opt,name=service,proto3" json:"service,omitempty"`
+	Remote        *Error                     `protobuf:"bytes,15,opt,name=remote,proto3" json:"remote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_errbox_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_errbox_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_errbox_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Error) GetCause() string {
+	if x != nil {
+		return x.Cause
+	}
+	return ""
+}
+
+func (x *Error) GetAnnotations() []*Annotation {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *Error) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Error) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Error) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Error) GetRetryable() bool {
+	if x != nil && x.Retryable != nil {
+		return *x.Retryable
+	}
+	return false
+}
+
+func (x *Error) GetUserMessage() string {
+	if x != nil {
+		return x.UserMessage
+	}
+	return ""
+}
+
+func (x *Error) GetHints() []string {
+	if x != nil {
+		return x.Hints
+	}
+	return nil
+}
+
+func (x *Error) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Error) GetFields() map[string]*structpb.Value {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *Error) GetSuppressed() []*Error {
+	if x != nil {
+		return x.Suppressed
+	}
+	return nil
+}
+
+func (x *Error) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Error) GetBox() *Box {
+	if x != nil {
+		return x.Box
+	}
+	return nil
+}
+
+func (x *Error) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Error) GetRemote() *Error {
+	if x != nil {
+		return x.Remote
+	}
+	return nil
+}
+
+type Annotation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	Function      string                 `protobuf:"bytes,4,opt,name=function,proto3" json:"function,omitempty"`
+	Repeated      int32                  `protobuf:"varint,5,opt,name=repeated,proto3" json:"repeated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Annotation) Reset() {
+	*x = Annotation{}
+	mi := &file_errbox_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Annotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Annotation) ProtoMessage() {}
+
+func (x *Annotation) ProtoReflect() protoreflect.Message {
+	mi := &file_errbox_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Annotation.ProtoReflect.Descriptor instead.
+func (*Annotation) Descriptor() ([]byte, []int) {
+	return file_errbox_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Annotation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Annotation) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Annotation) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Annotation) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *Annotation) GetRepeated() int32 {
+	if x != nil {
+		return x.Repeated
+	}
+	return 0
+}
+
+type Box struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Errors        []*Error               `protobuf:"bytes,1,rep,name=errors,proto3" json:"errors,omitempty"`
+	Dropped       int32                  `protobuf:"varint,2,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Box) Reset() {
+	*x = Box{}
+	mi := &file_errbox_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Box) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Box) ProtoMessage() {}
+
+func (x *Box) ProtoReflect() protoreflect.Message {
+	mi := &file_errbox_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Box.ProtoReflect.Descriptor instead.
+func (*Box) Descriptor() ([]byte, []int) {
+	return file_errbox_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Box) GetErrors() []*Error {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *Box) GetDropped() int32 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+var File_errbox_proto protoreflect.FileDescriptor
+
+const file_errbox_proto_rawDesc = "" +
+	"\n" +
+	"\ferrbox.proto\x12\terrbox.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xa4\x05\n" +
+	"\x05Error\x12\x14\n" +
+	"\x05cause\x18\x01 \x01(\tR\x05cause\x127\n" +
+	"\vannotations\x18\x02 \x03(\v2\x15.errbox.v1.AnnotationR\vannotations\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x12\n" +
+	"\x04kind\x18\x04 \x01(\tR\x04kind\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12!\n" +
+	"\tretryable\x18\x06 \x01(\bH\x00R\tretryable\x88\x01\x01\x12!\n" +
+	"\fuser_message\x18\a \x01(\tR\vuserMessage\x12\x14\n" +
+	"\x05hints\x18\b \x03(\tR\x05hints\x12.\n" +
+	"\x04tags\x18\t \x03(\v2\x1a.errbox.v1.Error.TagsEntryR\x04tags\x124\n" +
+	"\x06fields\x18\n" +
+	" \x03(\v2\x1c.errbox.v1.Error.FieldsEntryR\x06fields\x120\n" +
+	"\n" +
+	"suppressed\x18\v \x03(\v2\x10.errbox.v1.ErrorR\n" +
+	"suppressed\x12\x14\n" +
+	"\x05group\x18\f \x01(\tR\x05group\x12 \n" +
+	"\x03box\x18\r \x01(\v2\x0e.errbox.v1.BoxR\x03box\x12\x18\n" +
+	"\aservice\x18\x0e \x01(\tR\aservice\x12(\n" +
+	"\x06remote\x18\x0f \x01(\v2\x10.errbox.v1.ErrorR\x06remote\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aQ\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value:\x028\x01B\f\n" +
+	"\n" +
+	"_retryable\"\x86\x01\n" +
+	"\n" +
+	"Annotation\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x12\x1a\n" +
+	"\bfunction\x18\x04 \x01(\tR\bfunction\x12\x1a\n" +
+	"\brepeated\x18\x05 \x01(\x05R\brepeated\"I\n" +
+	"\x03Box\x12(\n" +
+	"\x06errors\x18\x01 \x03(\v2\x10.errbox.v1.ErrorR\x06errors\x12\x18\n" +
+	"\adropped\x18\x02 \x01(\x05R\adroppedB*Z(github.com/jan-herout/errbox/errboxprotob\x06proto3"
+
+var (
+	file_errbox_proto_rawDescOnce sync.Once
+	file_errbox_proto_rawDescData []byte
+)
+
+func file_errbox_proto_rawDescGZIP() []byte {
+	file_errbox_proto_rawDescOnce.Do(func() {
+		file_errbox_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_errbox_proto_rawDesc), len(file_errbox_proto_rawDesc)))
+	})
+	return file_errbox_proto_rawDescData
+}
+
+var file_errbox_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_errbox_proto_goTypes = []any{
+	(*Error)(nil),          // 0: errbox.v1.Error
+	(*Annotation)(nil),     // 1: errbox.v1.Annotation
+	(*Box)(nil),            // 2: errbox.v1.Box
+	nil,                    // 3: errbox.v1.Error.TagsEntry
+	nil,                    // 4: errbox.v1.Error.FieldsEntry
+	(*structpb.Value)(nil), // 5: google.protobuf.Value
+}
+var file_errbox_proto_depIdxs = []int32{
+	1, // 0: errbox.v1.Error.annotations:type_name -> errbox.v1.Annotation
+	3, // 1: errbox.v1.Error.tags:type_name -> errbox.v1.Error.TagsEntry
+	4, // 2: errbox.v1.Error.fields:type_name -> errbox.v1.Error.FieldsEntry
+	0, // 3: errbox.v1.Error.suppressed:type_name -> errbox.v1.Error
+	2, // 4: errbox.v1.Error.box:type_name -> errbox.v1.Box
+	0, // 5: errbox.v1.Error.remote:type_name -> errbox.v1.Error
+	0, // 6: errbox.v1.Box.errors:type_name -> errbox.v1.Error
+	5, // 7: errbox.v1.Error.FieldsEntry.value:type_name -> google.protobuf.Value
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_errbox_proto_init() }
+func file_errbox_proto_init() {
+	if File_errbox_proto != nil {
+		return
+	}
+	file_errbox_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_errbox_proto_rawDesc), len(file_errbox_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_errbox_proto_goTypes,
+		DependencyIndexes: file_errbox_proto_depIdxs,
+		MessageInfos:      file_errbox_proto_msgTypes,
+	}.Build()
+	File_errbox_proto = out.File
+	file_errbox_proto_goTypes = nil
+	file_errbox_proto_depIdxs = nil
+}
@@ -0,0 +1,10 @@
+module github.com/jan-herout/errbox/errboxproto
+
+go 1.23
+
+require (
+	github.com/jan-herout/errbox v0.0.0
+	google.golang.org/protobuf v1.36.12
+)
+
+replace github.com/jan-herout/errbox => ../
@@ -0,0 +1,15 @@
+module github.com/jan-herout/errbox/errboxsentry
+
+go 1.25.0
+
+require (
+	github.com/getsentry/sentry-go v0.49.0
+	github.com/jan-herout/errbox v0.0.0
+)
+
+require (
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/text v0.39.0 // indirect
+)
+
+replace github.com/jan-herout/errbox => ../
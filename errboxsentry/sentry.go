@@ -0,0 +1,139 @@
+/*
+Package errboxsentry converts errors from the errbox package to Sentry events, keeping their structure
+(exception chain from annotations, stack frames from places where the error was annotated, and tags
+from fields), instead of a single flattened string.
+
+It lives in a separate module, so that the errbox package itself does not depend on Sentry.
+*/
+package errboxsentry
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jan-herout/errbox"
+)
+
+// Tags set by Event, besides fields of the error.
+const (
+	TagCode = "errbox.code"
+	TagKind = "errbox.kind"
+)
+
+// Event converts the err to a Sentry event:
+//
+//   - the first exception is the cause of the err (see errbox.Cause), with stack frames of places where
+//     the err was annotated (see errbox.StackErr.Callers),
+//   - every annotation message adds an exception to the chain, so that the outermost annotation
+//     is the title of the issue; its type is the code of the err (see errbox.CodeOf), its kind
+//     (see errbox.KindOf), or the Go type of the cause, whichever is found first,
+//   - fields of the err, its code and its kind are set as tags (values formatted by fmt.Sprint),
+//   - the level is derived from the severity of the err (see errbox.SeverityOf).
+//
+// When the err is a *errbox.Box, the event describes its first error, see Events.
+// Nil is returned if the err is nil, or if it is an empty box.
+func Event(err error) *sentry.Event {
+	if b, ok := err.(*errbox.Box); ok {
+		errs := errbox.Errors(b)
+		if len(errs) == 0 {
+			return nil
+		}
+		err = errs[0]
+	}
+	if err == nil {
+		return nil
+	}
+	se := errbox.Inspect(err)
+	cause := errbox.Cause(se)
+
+	event := sentry.NewEvent()
+	event.Level = level(errbox.SeverityOf(se))
+	event.Exception = []sentry.Exception{{
+		Type:       fmt.Sprintf("%T", cause),
+		Value:      cause.Error(),
+		Stacktrace: stacktrace(se.Callers()),
+	}}
+	for _, anno := range se.Annotations() {
+		if anno.Message != "" {
+			event.Exception = append(event.Exception, sentry.Exception{Type: exceptionType(se), Value: anno.Message})
+		}
+	}
+
+	if code := errbox.CodeOf(se); code != "" {
+		event.Tags[TagCode] = code
+	}
+	if kind := errbox.KindOf(se); kind != errbox.KindUnknown {
+		event.Tags[TagKind] = kind.String()
+	}
+	for k, v := range se.CopyFields() {
+		event.Tags[k] = fmt.Sprint(v)
+	}
+	return event
+}
+
+// exceptionType returns the type of exceptions made of annotations of the err, see Event.
+func exceptionType(se *errbox.StackErr) string {
+	if code := errbox.CodeOf(se); code != "" {
+		return code
+	}
+	if kind := errbox.KindOf(se); kind != errbox.KindUnknown {
+		return kind.String()
+	}
+	return fmt.Sprintf("%T", errbox.Cause(se))
+}
+
+// Events converts the err to Sentry events, one event per error when the err is a *errbox.Box, see Event.
+func Events(err error) []*sentry.Event {
+	var events []*sentry.Event
+	for _, e := range errbox.Errors(err) {
+		if event := Event(e); event != nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Capture sends the err to Sentry by the hub (or by sentry.CurrentHub, if the hub is nil), one event per error
+// when the err is a *errbox.Box, see Event. It returns IDs of events which were sent.
+func Capture(hub *sentry.Hub, err error) []sentry.EventID {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	var ids []sentry.EventID
+	for _, event := range Events(err) {
+		if id := hub.CaptureEvent(event); id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	return ids
+}
+
+// stacktrace converts the program counters (innermost call first) to a Sentry stack trace, which lists frames
+// outermost call first. Nil is returned if there are no frames.
+func stacktrace(pcs []uintptr) *sentry.Stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []sentry.Frame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callers.Next()
+		frames = append([]sentry.Frame{sentry.NewFrame(frame)}, frames...)
+		if !more {
+			break
+		}
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// level converts the severity to a Sentry level.
+func level(severity errbox.Severity) sentry.Level {
+	switch severity {
+	case errbox.SeverityWarning:
+		return sentry.LevelWarning
+	case errbox.SeverityFatal:
+		return sentry.LevelFatal
+	}
+	return sentry.LevelError
+}
@@ -0,0 +1,50 @@
+package errboxsentry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jan-herout/errbox"
+)
+
+func TestEvent(t *testing.T) {
+	err := errbox.Annotate(errors.New("card declined"), "charging card")
+	err = errbox.WithField(errbox.Annotate(errbox.WithCode(err, "CARD_DECLINED"), "processing order"), "order_id", 42)
+
+	event := Event(err)
+	if len(event.Exception) != 3 {
+		t.Fatalf("expected three exceptions, got %#v", event.Exception)
+	}
+	cause := event.Exception[0]
+	if cause.Value != "card declined" || cause.Stacktrace == nil || len(cause.Stacktrace.Frames) != 2 {
+		t.Errorf("unexpected cause: %#v", cause)
+	}
+	if frame := cause.Stacktrace.Frames[0]; frame.Function != "TestEvent" || !strings.HasSuffix(frame.AbsPath, "sentry_test.go") {
+		t.Errorf("unexpected frame: %#v", frame)
+	}
+	if title := event.Exception[2]; title.Type != "CARD_DECLINED" || title.Value != "processing order" {
+		t.Errorf("expected the outermost annotation to be the title, got %#v", title)
+	}
+	if typ := Event(errbox.Annotate(errors.New("boom"), "loading")).Exception[1].Type; typ != "*errors.errorString" {
+		t.Errorf("expected the type of the cause, got %q", typ)
+	}
+	if event.Tags["order_id"] != "42" || event.Tags[TagCode] != "CARD_DECLINED" {
+		t.Errorf("unexpected tags: %v", event.Tags)
+	}
+	if event.Level != sentry.LevelError {
+		t.Errorf("unexpected level %v", event.Level)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	box := errbox.Append(errbox.Append(nil, errors.New("a")), errbox.WithSeverity(errors.New("b"), errbox.SeverityWarning))
+	events := Events(box)
+	if len(events) != 2 || events[1].Level != sentry.LevelWarning {
+		t.Errorf("unexpected events: %#v", events)
+	}
+	if Event(nil) != nil || Events(nil) != nil {
+		t.Errorf("expected no events")
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/jan-herout/errbox/errboxzap
+
+go 1.21
+
+require (
+	github.com/jan-herout/errbox v0.0.0
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/jan-herout/errbox => ../
@@ -0,0 +1,90 @@
+/*
+Package errboxzap logs errors from the errbox package with zap, keeping their structure
+(cause, annotations, stack frames and fields) instead of a single flattened string.
+
+It lives in a separate module, so that the errbox package itself does not depend on zap.
+*/
+package errboxzap
+
+import (
+	"log/slog"
+
+	"github.com/jan-herout/errbox"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Field returns a zap.Field with the key "error", which logs the err as a structured object:
+//
+//	logger.Error("failed", errboxzap.Field(err))
+//
+// If the err is nil, the field is skipped.
+func Field(err error) zap.Field {
+	return NamedField("error", err)
+}
+
+// NamedField works like Field, but uses the key instead of "error".
+func NamedField(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, Object(err))
+}
+
+// Object returns the err as zapcore.ObjectMarshaler. The object has the same structure as the one produced
+// by errbox.SlogAttrs.
+func Object(err error) zapcore.ObjectMarshaler {
+	return attrs(errbox.SlogAttrs(err))
+}
+
+// attrs encodes list of slog attributes as an object.
+type attrs []slog.Attr
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (a attrs) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range a {
+		if err := addValue(enc, attr.Key, attr.Value.Resolve()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringArray encodes list of strings as an array.
+type stringArray []string
+
+// MarshalLogArray implements zapcore.ArrayMarshaler.
+func (s stringArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range s {
+		enc.AppendString(v)
+	}
+	return nil
+}
+
+// addValue adds the value to the encoder, under the key.
+func addValue(enc zapcore.ObjectEncoder, key string, v slog.Value) error {
+	switch v.Kind() {
+	case slog.KindGroup:
+		return enc.AddObject(key, attrs(v.Group()))
+	case slog.KindString:
+		enc.AddString(key, v.String())
+	case slog.KindInt64:
+		enc.AddInt64(key, v.Int64())
+	case slog.KindUint64:
+		enc.AddUint64(key, v.Uint64())
+	case slog.KindFloat64:
+		enc.AddFloat64(key, v.Float64())
+	case slog.KindBool:
+		enc.AddBool(key, v.Bool())
+	case slog.KindDuration:
+		enc.AddDuration(key, v.Duration())
+	case slog.KindTime:
+		enc.AddTime(key, v.Time())
+	default:
+		if s, ok := v.Any().([]string); ok {
+			return enc.AddArray(key, stringArray(s))
+		}
+		return enc.AddReflected(key, v.Any())
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package errboxzap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestField(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	err := errbox.WithField(errbox.Annotate(fmt.Errorf("boom"), "processing order"), "order_id", 42)
+	logger.Error("failed", Field(err), Field(nil))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+	ctx := entries[0].ContextMap()
+	obj, ok := ctx["error"].(map[string]interface{})
+	if !ok || len(ctx) != 1 {
+		t.Fatalf("expected error object, got: %#v", ctx)
+	}
+	if obj["cause"] != "boom" {
+		t.Errorf("unexpected cause: %#v", obj)
+	}
+	if fields, ok := obj["fields"].(map[string]interface{}); !ok || fields["order_id"] != int64(42) {
+		t.Errorf("unexpected fields: %#v", obj)
+	}
+	if a, ok := obj["annotations"].([]interface{}); !ok || len(a) != 1 {
+		t.Errorf("unexpected annotations: %#v", obj)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/jan-herout/errbox/errboxzerolog
+
+go 1.23
+
+require (
+	github.com/jan-herout/errbox v0.0.0
+	github.com/rs/zerolog v1.35.1
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+)
+
+replace github.com/jan-herout/errbox => ../
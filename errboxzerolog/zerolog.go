@@ -0,0 +1,75 @@
+/*
+Package errboxzerolog logs errors from the errbox package with zerolog, keeping their structure
+(cause, annotations, stack frames and fields), which is otherwise lost by zerolog's default error serialization.
+
+It lives in a separate module, so that the errbox package itself does not depend on zerolog.
+*/
+package errboxzerolog
+
+import (
+	"log/slog"
+
+	"github.com/jan-herout/errbox"
+	"github.com/rs/zerolog"
+)
+
+// Marshal writes the err into the event under the key "error", as a structured object, and returns the event back:
+//
+//	errboxzerolog.Marshal(log.Error(), err).Msg("failed")
+//
+// If the err is nil, the event is not changed.
+func Marshal(e *zerolog.Event, err error) *zerolog.Event {
+	return MarshalKey(e, zerolog.ErrorFieldName, err)
+}
+
+// MarshalKey works like Marshal, but uses the key instead of "error".
+func MarshalKey(e *zerolog.Event, key string, err error) *zerolog.Event {
+	if err == nil {
+		return e
+	}
+	return e.Object(key, Object(err))
+}
+
+// Object returns the err as zerolog.LogObjectMarshaler. The object has the same structure as the one produced
+// by errbox.SlogAttrs.
+func Object(err error) zerolog.LogObjectMarshaler {
+	return attrs(errbox.SlogAttrs(err))
+}
+
+// attrs encodes list of slog attributes as an object.
+type attrs []slog.Attr
+
+// MarshalZerologObject implements zerolog.LogObjectMarshaler.
+func (a attrs) MarshalZerologObject(e *zerolog.Event) {
+	for _, attr := range a {
+		addValue(e, attr.Key, attr.Value.Resolve())
+	}
+}
+
+// addValue adds the value to the event, under the key.
+func addValue(e *zerolog.Event, key string, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindGroup:
+		e.Object(key, attrs(v.Group()))
+	case slog.KindString:
+		e.Str(key, v.String())
+	case slog.KindInt64:
+		e.Int64(key, v.Int64())
+	case slog.KindUint64:
+		e.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		e.Float64(key, v.Float64())
+	case slog.KindBool:
+		e.Bool(key, v.Bool())
+	case slog.KindDuration:
+		e.Dur(key, v.Duration())
+	case slog.KindTime:
+		e.Time(key, v.Time())
+	default:
+		if s, ok := v.Any().([]string); ok {
+			e.Strs(key, s)
+			return
+		}
+		e.Interface(key, v.Any())
+	}
+}
@@ -0,0 +1,39 @@
+package errboxzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+	"github.com/rs/zerolog"
+)
+
+func TestMarshal(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	err := errbox.WithField(errbox.Annotate(fmt.Errorf("boom"), "processing order"), "order_id", 42)
+	Marshal(logger.Error(), err).Msg("failed")
+
+	var record struct {
+		Error struct {
+			Cause       string
+			Annotations []string
+			Fields      map[string]int
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("cannot parse log: %v", err)
+	}
+	if record.Error.Cause != "boom" || len(record.Error.Annotations) != 1 || record.Error.Fields["order_id"] != 42 {
+		t.Errorf("unexpected structure of the error: %s", buf.String())
+	}
+
+	buf.Reset()
+	Marshal(logger.Info(), nil).Msg("ok")
+	if bytes.Contains(buf.Bytes(), []byte("error")) {
+		t.Errorf("expected no error in the log: %s", buf.String())
+	}
+}
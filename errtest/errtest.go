@@ -0,0 +1,106 @@
+/*
+Package errtest provides assertions for tests of code which returns errors from the errbox package,
+so that table tests do not need to inspect boxes by hand. Failures are reported in a diff-like form,
+with the whole error printed out below:
+
+	errtest.AssertInside(t, err, os.ErrNotExist)
+	errtest.AssertCode(t, err, "E1021")
+	errtest.AssertAnnotated(t, err, "loading config")
+*/
+package errtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+)
+
+// AssertInside reports a failure if the target is not inside the err, see errbox.IsInside.
+// It returns true if the assertion passed.
+func AssertInside(t testing.TB, err, target error) bool {
+	t.Helper()
+	if errbox.IsInside(err, target) {
+		return true
+	}
+	var got []string
+	for _, e := range errbox.Errors(err) {
+		got = append(got, errbox.Cause(e).Error())
+	}
+	t.Errorf("errtest: error is not inside\n%s", report(err, fmt.Sprint(target), got))
+	return false
+}
+
+// AssertCode reports a failure if the code of the err is not the code, see errbox.CodeOf.
+// It returns true if the assertion passed.
+func AssertCode(t testing.TB, err error, code string) bool {
+	t.Helper()
+	got := errbox.CodeOf(err)
+	if got == code {
+		return true
+	}
+	t.Errorf("errtest: unexpected code\n%s", report(err, code, []string{got}))
+	return false
+}
+
+// AssertAnnotated reports a failure if no annotation of the err (or of errors it wraps, or of errors in boxes)
+// contains the substring. It returns true if the assertion passed.
+func AssertAnnotated(t testing.TB, err error, substring string) bool {
+	t.Helper()
+	got := annotations(err)
+	for _, message := range got {
+		if strings.Contains(message, substring) {
+			return true
+		}
+	}
+	t.Errorf("errtest: no annotation contains %q\n%s", substring, report(err, "..."+substring+"...", got))
+	return false
+}
+
+// AssertNoError reports a failure if the err is not nil. It returns true if the assertion passed.
+func AssertNoError(t testing.TB, err error) bool {
+	t.Helper()
+	if err == nil {
+		return true
+	}
+	t.Errorf("errtest: unexpected error\n%s", report(err, "<nil>", nil))
+	return false
+}
+
+// annotations returns annotation messages of all errors in the tree of the err, see errbox.Walk.
+func annotations(err error) []string {
+	var messages []string
+	errbox.Walk(err, func(e error) bool {
+		se, ok := e.(*errbox.StackErr)
+		if !ok {
+			return true
+		}
+		for _, anno := range se.Annotations() {
+			if anno.Message != "" {
+				messages = append(messages, anno.Message)
+			}
+		}
+		return true
+	})
+	return messages
+}
+
+// report formats the expected value, the values which were found instead, and the err itself.
+func report(err error, want string, got []string) string {
+	var sb strings.Builder
+	sb.WriteString("- " + want + "\n")
+	if len(got) == 0 {
+		sb.WriteString("+ (nothing)\n")
+	}
+	for _, g := range got {
+		sb.WriteString("+ " + g + "\n")
+	}
+	if err != nil {
+		sb.WriteString("error:\n")
+		for _, line := range strings.Split(strings.TrimRight(err.Error(), "\n"), "\n") {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+	return sb.String()
+}
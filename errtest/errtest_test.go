@@ -0,0 +1,47 @@
+package errtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jan-herout/errbox"
+)
+
+// recorder records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	err := errbox.Annotate(errbox.WithCode(os.ErrNotExist, "E_CONFIG"), "loading config")
+	box := errbox.Append(errbox.Append(nil, io.EOF), err)
+
+	r := &recorder{TB: t}
+	if !AssertInside(r, box, os.ErrNotExist) || !AssertCode(r, box, "E_CONFIG") || !AssertAnnotated(r, box, "config") {
+		t.Errorf("expected assertions to pass, got %q", r.failures)
+	}
+	if !AssertNoError(r, nil) {
+		t.Errorf("expected nil error to pass")
+	}
+
+	if AssertAnnotated(r, box, "loading users") || AssertInside(r, err, io.ErrUnexpectedEOF) || AssertCode(r, errors.New("x"), "E1") {
+		t.Errorf("expected assertions to fail")
+	}
+	if len(r.failures) != 3 {
+		t.Fatalf("expected three failures, got %q", r.failures)
+	}
+	if f := r.failures[0]; !strings.Contains(f, "- ...loading users...\n+ loading config\n") || !strings.Contains(f, "error:\n") {
+		t.Errorf("unexpected failure output:\n%s", f)
+	}
+}
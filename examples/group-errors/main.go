@@ -28,6 +28,6 @@ func main() {
 	var be errbox.Box
 	be.PushIf(fmt.Errorf("bad stuff happened"), "because we were careless")
 	if be.PushIf(fmt.Errorf("after that, another bad thing happened"), "karma!") {
-		fmt.Println(be)
+		fmt.Println(&be)
 	}
 }
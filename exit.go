@@ -0,0 +1,110 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// exitCodes holds mappings registered by RegisterExitCode and RegisterExitError, protected by exitMu.
+var (
+	exitCodes  = make(map[string]int)
+	exitErrors []exitError
+	exitMu     sync.RWMutex
+)
+
+// exitError maps the sentinel error to the exit code.
+type exitError struct {
+	target error
+	code   int
+}
+
+// osExit and exitOutput are used by Exit, they are replaced by tests.
+var (
+	osExit               = os.Exit
+	exitOutput io.Writer = os.Stderr
+)
+
+// RegisterExitCode registers the process exit code for errors with the code (see WithCode), see ExitCode.
+// It is meant to be called from init functions, but it is safe for concurrent use.
+// Registering the same code again replaces the exit code.
+func RegisterExitCode(code string, exitCode int) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitCodes[code] = exitCode
+}
+
+// RegisterExitError registers the process exit code for errors which are the target (think errors.Is),
+// see ExitCode. It is meant to be called from init functions, but it is safe for concurrent use.
+// When the error matches more targets, the one registered first wins.
+//
+//	func init() {
+//		errbox.RegisterExitError(os.ErrNotExist, 2)
+//	}
+func RegisterExitError(target error, exitCode int) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitErrors = append(exitErrors, exitError{target: target, code: exitCode})
+}
+
+// ExitCode returns the exit code which the program should return because of the err:
+//
+//   - 0 is returned if the err is nil, or if it is a warning (see SeverityWarning),
+//   - the exit code registered for the code of the err is returned (see RegisterExitCode and CodeOf),
+//   - the exit code registered for a sentinel error which the err is (see RegisterExitError) is returned,
+//   - otherwise, 1 is returned.
+//
+// When the err is a *Box, or it wraps one (like a group, see Box.Group), the highest exit code of all errors
+// in the box is returned, unless the err is marked as a warning.
+func ExitCode(err error) int {
+	if isNil(err) {
+		return 0
+	}
+	if s, ok := lookupOuter(err, (*StackErr).ownSeverity); ok && s == SeverityWarning {
+		return 0
+	}
+	if b := boxIn(err); b != nil {
+		exitCode := 0
+		for _, e := range Errors(b) {
+			if c := ExitCode(e); c > exitCode {
+				exitCode = c
+			}
+		}
+		return exitCode
+	}
+
+	exitMu.RLock()
+	defer exitMu.RUnlock()
+	if code := CodeOf(err); code != "" {
+		if exitCode, ok := exitCodes[code]; ok {
+			return exitCode
+		}
+	}
+	for _, e := range exitErrors {
+		if errors.Is(err, e.target) {
+			return e.code
+		}
+	}
+	return 1
+}
+
+// Exit prints the err out to stderr (if it is not nil), and exits the program with ExitCode of the err.
+// The stack trace is printed out only if it is enabled (see ShowStack). It is meant to be called at the end
+// of main of CLI programs:
+//
+//	func main() {
+//		errbox.Exit(run())
+//	}
+func Exit(err error) {
+	if err != nil {
+		msg := err.Error()
+		if !strings.HasSuffix(msg, "\n") {
+			msg += "\n"
+		}
+		fmt.Fprint(exitOutput, msg)
+	}
+	osExit(ExitCode(err))
+}
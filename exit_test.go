@@ -0,0 +1,54 @@
+package errbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	RegisterExitCode("USAGE", 64)
+	RegisterExitError(os.ErrNotExist, 2)
+
+	mixed := NewBox()
+	mixed.PushIf(WithSeverity(errors.New("deprecated flag"), SeverityWarning), "")
+	mixed.PushIf(os.ErrNotExist, "")
+	grouped := NewBox()
+	grouped.AddGroup("config", mixed)
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), 1},
+		{WithCode(errors.New("unknown flag"), "USAGE"), 64},
+		{Annotate(os.ErrNotExist, "opening config"), 2},
+		{WithSeverity(errors.New("deprecated flag"), SeverityWarning), 0},
+		{Append(Append(nil, os.ErrNotExist), WithCode(errors.New("bad flag"), "USAGE")), 64},
+		{grouped, 2},
+		{fmt.Errorf("running: %w", mixed), 2},
+		{WithSeverity(fmt.Errorf("running: %w", mixed), SeverityWarning), 0},
+	}
+	for i, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("#%d: expected %d, got %d", i, tt.want, got)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		code = -1
+	)
+	osExit, exitOutput = func(c int) { code = c }, &out
+	defer func() { osExit, exitOutput = os.Exit, os.Stderr }()
+
+	Exit(errors.New("boom"))
+	if code != 1 || out.String() != "boom\n" {
+		t.Errorf("unexpected exit %d with output %q", code, out.String())
+	}
+}
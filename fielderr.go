@@ -0,0 +1,50 @@
+package errbox
+
+import "errors"
+
+// FieldError is a validation error of one field of an input, like "user.address.zip: invalid".
+// See FieldErr and Box.ByField.
+type FieldError struct {
+	Path    string `json:"path"`    // path of the field, like "user.address.zip"
+	Message string `json:"message"` // what is wrong with the field, like "invalid"
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// FieldErr returns a new error (as *StackErr) of the field with the path, with the message formatted
+// by fmt.Sprintf, annotated with the place where FieldErr was called. The error is of KindInvalid,
+// and it can be found by errors.As as *FieldError:
+//
+//	box.PushIf(errbox.FieldErr("user.address.zip", "invalid zip code %q", zip), "")
+func FieldErr(path string, message string, args ...interface{}) error {
+	be, _ := withStack(&FieldError{Path: path, Message: formatMessage(message, args)})
+	be.kind = KindInvalid
+	be.annotate(2, "")
+	created(be)
+	return be
+}
+
+// ByField returns messages of field errors in the box (see FieldErr), grouped by paths of the fields, so that
+// API handlers can return per-field errors, which serialize naturally to JSON:
+//
+//	{"user.address.zip": ["invalid zip code \"x\""], "user.email": ["is required"]}
+//
+// Errors in the box which are not field errors are listed under the empty path. Returns nil if the box is empty.
+func (b *Box) ByField() map[string][]string {
+	var fields map[string][]string
+	for _, err := range Errors(b) {
+		if fields == nil {
+			fields = make(map[string][]string)
+		}
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			fields[fe.Path] = append(fields[fe.Path], fe.Message)
+			continue
+		}
+		fields[""] = append(fields[""], Cause(err).Error())
+	}
+	return fields
+}
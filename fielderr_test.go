@@ -0,0 +1,38 @@
+package errbox
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFieldErr(t *testing.T) {
+	err := FieldErr("user.address.zip", "invalid zip code %q", "x")
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "user.address.zip" || Cause(err).Error() != `user.address.zip: invalid zip code "x"` {
+		t.Errorf("unexpected field error %v", err)
+	}
+	if KindOf(err) != KindInvalid {
+		t.Errorf("expected KindInvalid, got %v", KindOf(err))
+	}
+}
+
+func TestBoxByField(t *testing.T) {
+	box := NewBox()
+	if box.ByField() != nil {
+		t.Errorf("expected nil")
+	}
+	box.PushIf(FieldErr("user.email", "is required"), "")
+	box.PushIf(FieldErr("user.address.zip", "invalid"), "")
+	box.PushIf(FieldErr("user.email", "is too long"), "")
+	box.PushIf(errors.New("rate limited"), "")
+
+	data, err := json.Marshal(box.ByField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"":["rate limited"],"user.address.zip":["invalid"],"user.email":["is required","is too long"]}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
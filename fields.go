@@ -0,0 +1,206 @@
+package errbox
+
+import "errors"
+
+// Fields returns a map, which can be used to store or fetch anything. Typically, you would use it as follows:
+//
+//	stacked := WithStack(err)
+//	fields := stacked.Fields()
+//	fields["whatever"] = "whatever"  // set it to string
+//	x := stacked.StringField("whatever")  // get it back
+//
+// The map itself is NOT mutex protected. If the error is shared between goroutines (for example, it is logged
+// by one goroutine while another one adds context to it), use SetField, GetField and CopyFields instead.
+func (b *StackErr) Fields() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fieldMap()
+}
+
+// fieldMap returns the fields, creating the map if needed. The caller must hold the lock.
+func (b *StackErr) fieldMap() map[string]interface{} {
+	if b.fields == nil {
+		b.fields = make(map[string]interface{})
+	}
+	return b.fields
+}
+
+// SetField sets the field with the name to the value. It is safe for concurrent use.
+func (b *StackErr) SetField(name string, value interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fieldMap()[name] = value
+}
+
+// GetField returns value of the field with the name, and true if it was found. It is safe for concurrent use.
+func (b *StackErr) GetField(name string) (interface{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.fields[name]
+	return v, ok
+}
+
+// CopyFields returns a copy of all fields, which the caller is free to modify. It is safe for concurrent use.
+// Nil is returned if the error has no fields.
+func (b *StackErr) CopyFields() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.fields) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(b.fields))
+	for k, v := range b.fields {
+		m[k] = v
+	}
+	return m
+}
+
+// StringField attempts to access a field, convert it to a string, and return it.
+// The function returns empty string if the field was not found, or if the value could not be converted to string.
+func (b *StackErr) StringField(name string) string {
+	i, ok := b.GetField(name)
+	if !ok {
+		return ""
+	}
+	s, ok := i.(string)
+	if ok {
+		return s
+	}
+	return ""
+}
+
+// WithField sets the field with the name to the value, and returns the error back, so that calls can be chained:
+//
+//	return errbox.WithStack(err).WithField("order_id", id).WithField("customer", name)
+func (b *StackErr) WithField(name string, value interface{}) *StackErr {
+	b.SetField(name, value)
+	return b
+}
+
+// WithFields sets all the fields, and returns the error back. See WithField.
+func (b *StackErr) WithFields(fields map[string]interface{}) *StackErr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := b.fieldMap()
+	for k, v := range fields {
+		m[k] = v
+	}
+	return b
+}
+
+// WithField returns the err (as *StackErr) with the field set to the value, or nil, if the err is nil.
+// It is meant to be used at wrap time, in one expression:
+//
+//	return errbox.WithField(errbox.Annotate(err, "order failed"), "order_id", id)
+//
+// Call of WithField on error which is a *Box sets the field on all errors in the box, including errors in groups.
+func WithField(err error, name string, value interface{}) error {
+	return WithFields(err, map[string]interface{}{name: value})
+}
+
+// WithFields returns the err (as *StackErr) with all the fields set, or nil, if the err is nil. See WithField.
+func WithFields(err error, fields map[string]interface{}) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range b.leaves() {
+			e.WithFields(fields)
+		}
+		return b
+	}
+	return WithStack(err).WithFields(fields)
+}
+
+// Field looks for the field with the name on the err (see FieldOf), and returns its value converted to the type T.
+// The second return value is false if the field was not found, or if its value is not of the type T.
+//
+//	id, ok := errbox.Field[int](err, "order_id")
+func Field[T any](err error, name string) (T, bool) {
+	var zero T
+	v, ok := lookupField(err, name)
+	if !ok {
+		return zero, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
+
+// inheritFields copies fields of all errors wrapped by the cause (see errors.Unwrap) to the error,
+// so that context attached near the source survives re-wrapping at higher layers.
+// When more errors have the same field, the outermost one wins.
+func (b *StackErr) inheritFields() {
+	var chain []*StackErr
+	for err := b.cause; !isNil(err); err = errors.Unwrap(err) {
+		if se, ok := err.(*StackErr); ok {
+			chain = append(chain, se)
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if fields := chain[i].CopyFields(); len(fields) > 0 {
+			b.WithFields(fields)
+		}
+	}
+}
+
+// FieldOf looks for the field with the name on the err, on all errors it wraps (see errors.Unwrap),
+// and on all errors stored in boxes found along the way. Returns the value, and true if the field was found.
+//
+// The outermost error wins; in a box, the first error which has the field wins.
+func FieldOf(err error, name string) (interface{}, bool) {
+	return lookupField(err, name)
+}
+
+// StringFieldOf works like FieldOf, and converts the value to string.
+// It returns empty string if the field was not found, or if the value could not be converted to string.
+// Use Field for other types.
+func StringFieldOf(err error, name string) string {
+	s, _ := Field[string](err, name)
+	return s
+}
+
+// lookupField implements FieldOf.
+func lookupField(err error, name string) (interface{}, bool) {
+	return lookup(err, func(se *StackErr) (interface{}, bool) {
+		return se.GetField(name)
+	})
+}
+
+// FieldCollision decides what happens when more errors in a box have a field with the same name, see Box.Fields.
+type FieldCollision int
+
+const (
+	// FirstWins keeps the value of the first error in the box.
+	FirstWins FieldCollision = iota
+	// LastWins keeps the value of the last error in the box.
+	LastWins
+	// CollectAll collects values of all errors to []interface{}, in order of errors in the box.
+	// Every field is a slice, even if only one error has it.
+	CollectAll
+)
+
+// Fields merges fields of all errors in the box into a new map, so that a single structured log entry can carry
+// the combined context of the whole batch. The collision decides which value is used when more errors have
+// a field with the same name.
+//
+// Nil is returned if no error in the box has any field.
+func (b *Box) Fields(collision FieldCollision) map[string]interface{} {
+	var merged map[string]interface{}
+	for _, err := range Errors(b) {
+		for k, v := range WithStack(err).CopyFields() {
+			if merged == nil {
+				merged = make(map[string]interface{})
+			}
+			prev, found := merged[k]
+			switch {
+			case collision == CollectAll && found:
+				merged[k] = append(prev.([]interface{}), v)
+			case collision == CollectAll:
+				merged[k] = []interface{}{v}
+			case collision == LastWins || !found:
+				merged[k] = v
+			}
+		}
+	}
+	return merged
+}
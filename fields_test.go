@@ -0,0 +1,110 @@
+package errbox
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestField(t *testing.T) {
+	inner := WithStack(fmt.Errorf("boom"))
+	inner.Fields()["order_id"] = 42
+	err := Annotate(fmt.Errorf("wrapped: %w", inner), "outer")
+
+	if id, ok := Field[int](err, "order_id"); !ok || id != 42 {
+		t.Errorf("expected to find order_id=42, got %v, %v", id, ok)
+	}
+	if _, ok := Field[string](err, "order_id"); ok {
+		t.Errorf("expected order_id not to be a string")
+	}
+	if _, ok := Field[int](err, "n/a"); ok {
+		t.Errorf("expected missing field not to be found")
+	}
+}
+
+func TestWithField(t *testing.T) {
+	err := WithField(Annotate(fmt.Errorf("boom"), "order failed"), "order_id", 42)
+	err = WithStack(err).WithField("customer", "acme")
+	if id, ok := Field[int](err, "order_id"); !ok || id != 42 {
+		t.Errorf("expected to find order_id=42, got %v, %v", id, ok)
+	}
+	if c := WithStack(err).StringField("customer"); c != "acme" {
+		t.Errorf("expected customer=acme, got %q", c)
+	}
+	if WithField(nil, "order_id", 42) != nil {
+		t.Errorf("expected nil error to stay nil")
+	}
+
+	box := Append(fmt.Errorf("one"), fmt.Errorf("two"))
+	WithFields(box, map[string]interface{}{"batch": 7})
+	for _, e := range Errors(box) {
+		if b, ok := Field[int](e, "batch"); !ok || b != 7 {
+			t.Errorf("expected batch=7 on %v", e)
+		}
+	}
+}
+
+func TestConcurrentFields(t *testing.T) {
+	err := WithStack(fmt.Errorf("boom"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err.SetField(fmt.Sprintf("k%d", i), j)
+				_ = err.CopyFields()
+				_, _ = Field[int](err, "k0")
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := len(err.CopyFields()); n != 4 {
+		t.Errorf("expected 4 fields, got %d", n)
+	}
+}
+
+func TestFieldOf(t *testing.T) {
+	deep := WithStack(fmt.Errorf("boom")).WithField("file", "data.csv")
+	box := Append(fmt.Errorf("unrelated"), Annotate(deep, "reading"))
+	err := Annotate(fmt.Errorf("batch failed: %w", box), "three layers up")
+
+	if f := StringFieldOf(err, "file"); f != "data.csv" {
+		t.Errorf("expected file=data.csv, got %q", f)
+	}
+	if _, ok := FieldOf(err, "n/a"); ok {
+		t.Errorf("expected missing field not to be found")
+	}
+}
+
+func TestBoxFields(t *testing.T) {
+	b := NewBox()
+	b.PushIf(WithField(fmt.Errorf("one"), "shard", 1), "")
+	b.PushIf(WithFields(fmt.Errorf("two"), map[string]interface{}{"shard": 2, "file": "b.csv"}), "")
+
+	if f := b.Fields(FirstWins); f["shard"] != 1 || f["file"] != "b.csv" {
+		t.Errorf("unexpected fields: %#v", f)
+	}
+	if f := b.Fields(LastWins); f["shard"] != 2 {
+		t.Errorf("unexpected fields: %#v", f)
+	}
+	if f := b.Fields(CollectAll); len(f["shard"].([]interface{})) != 2 || len(f["file"].([]interface{})) != 1 {
+		t.Errorf("unexpected fields: %#v", f)
+	}
+	if NewBox().Fields(FirstWins) != nil {
+		t.Errorf("expected no fields on empty box")
+	}
+}
+
+func TestInheritFields(t *testing.T) {
+	inner := WithStack(fmt.Errorf("boom")).WithField("order_id", 42).WithField("layer", "inner")
+	middle := WithStack(fmt.Errorf("middle: %w", inner)).WithField("layer", "middle")
+	outer := WithStack(Annotate(fmt.Errorf("outer: %w", middle), "top"))
+
+	if id := outer.Fields()["order_id"]; id != 42 {
+		t.Errorf("expected order_id to be inherited, got: %#v", outer.Fields())
+	}
+	if l := outer.StringField("layer"); l != "middle" {
+		t.Errorf("expected the outermost value to win, got %q", l)
+	}
+}
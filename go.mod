@@ -1,3 +1,3 @@
 module github.com/jan-herout/errbox
 
-go 1.16
+go 1.21
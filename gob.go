@@ -0,0 +1,53 @@
+package errbox
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+)
+
+func init() {
+	// errors are typically stored in fields of the type error, gob needs to know the concrete types
+	gob.Register(new(StackErr))
+	gob.Register(new(Box))
+}
+
+// GobEncode implements gob.GobEncoder, so that errors can be persisted (for example, in a job queue), and replayed
+// later with their annotations intact. The error is encoded in the same way as by MarshalJSON, see FromJSON
+// for what is kept.
+func (b *StackErr) GobEncode() ([]byte, error) {
+	return b.MarshalJSON()
+}
+
+// GobDecode implements gob.GobDecoder, see GobEncode. The error must be a new, unused one.
+func (b *StackErr) GobDecode(data []byte) error {
+	var je Record
+	if err := json.Unmarshal(data, &je); err != nil {
+		return err
+	}
+	if je.Cause == nil && je.Group == "" {
+		return errors.New("errbox: can not decode a box as StackErr")
+	}
+	je.decodeStackErr(b)
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder, see StackErr.GobEncode.
+func (b *Box) GobEncode() ([]byte, error) {
+	return b.MarshalJSON()
+}
+
+// GobDecode implements gob.GobDecoder, see StackErr.GobEncode. Decoded errors are appended to the box.
+func (b *Box) GobDecode(data []byte) error {
+	var je Record
+	if err := json.Unmarshal(data, &je); err != nil {
+		return err
+	}
+	if je.Cause != nil || je.Group != "" {
+		return errors.New("errbox: can not decode StackErr as a box")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	je.decodeBox(b)
+	return nil
+}
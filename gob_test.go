@@ -0,0 +1,33 @@
+package errbox
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+func TestGob(t *testing.T) {
+	type job struct {
+		Name string
+		Err  error
+	}
+	box := NewBox()
+	box.PushIf(WithCode(errors.New("timeout"), "E_TIMEOUT"), "calling %s", "billing")
+	box.PushIf(errors.New("bad row"), "importing")
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(job{Name: "import", Err: box}); err != nil {
+		t.Fatal(err)
+	}
+	var got job
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Err.Error() != box.Error() {
+		t.Errorf("expected the same rendering, got:\n%s\nwant:\n%s", got.Err, box)
+	}
+	if CodeOf(got.Err) != "E_TIMEOUT" {
+		t.Errorf("code was lost")
+	}
+}
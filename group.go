@@ -0,0 +1,184 @@
+package errbox
+
+import (
+	"context"
+	"fmt"
+)
+
+// Go runs the fn in a new goroutine, and pushes the error returned by the fn (if any) into the box.
+// If the fn panics, the panic is recovered and pushed into the box as well (see FromPanic).
+// Stack of the caller is attached to the error (see Origin), so the trace shows where the goroutine was launched.
+//
+// Use Wait to wait for all goroutines started by Go. Unlike errgroup, the box collects all errors,
+// not only the first one:
+//
+//	var box errbox.Box
+//	for _, f := range files {
+//		f := f
+//		box.Go(func() error { return process(f) })
+//	}
+//	if err := box.Wait(); err != nil {
+//		return err
+//	}
+//
+// If the number of active goroutines reached the limit (see SetLimit), Go blocks until one of them finishes.
+func (b *Box) Go(fn func() error) {
+	b.goWith(captureOrigin(3), fn, b.pushErr)
+}
+
+// goWith implements Go, the origin is attached to errors of the fn, which are then passed to the push.
+func (b *Box) goWith(origin *Origin, fn func() error, push func(err error)) {
+	b.mu.Lock()
+	sem := b.sem
+	b.mu.Unlock()
+	if sem != nil {
+		sem <- struct{}{}
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		if sem != nil {
+			defer func() { <-sem }()
+		}
+		defer func() {
+			if pe := FromPanic(recover()); pe != nil {
+				push(origin.Attach(pe))
+			}
+		}()
+		push(origin.Attach(fn()))
+	}()
+}
+
+// SetLimit limits the number of goroutines started by Go which are active at the same time to at most n,
+// so that fan-out batch operations can be throttled while still collecting every error.
+// A negative value indicates no limit.
+//
+// The limit must not be modified while any goroutine started by Go is active.
+func (b *Box) SetLimit(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sem != nil && len(b.sem) != 0 {
+		panic(fmt.Errorf("errbox: modify limit while %v goroutines in the box are still active", len(b.sem)))
+	}
+	if n < 0 {
+		b.sem = nil
+		return
+	}
+	b.sem = make(chan struct{}, n)
+}
+
+// WithContext returns a context derived from the ctx, which is cancelled as soon as an error for which the fatal
+// function returns true is pushed into the box (in any way, not only by Go). If the fatal function is nil,
+// any error is fatal. The cause of the cancellation (see context.Cause) is the fatal error.
+//
+// This way, a parallel pipeline can abort early, while still aggregating partial failures:
+//
+//	var box errbox.Box
+//	ctx = box.WithContext(ctx, func(err error) bool { return !errbox.IsInside(err, ErrSkipped) })
+//	for _, item := range items {
+//		item := item
+//		box.Go(func() error { return process(ctx, item) })
+//	}
+//	err := box.Wait()
+//
+// The fatal function is called with the box locked, therefore it must NOT call methods of the box.
+func (b *Box) WithContext(ctx context.Context, fatal func(err error) bool) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancel = cancel
+	b.fatal = fatal
+	return ctx
+}
+
+// Wait waits until all goroutines started by Go are finished. It returns the box, if it contains any error,
+// or nil, if it is empty. The context returned by WithContext (if any) is cancelled then, like by errgroup.
+func (b *Box) Wait() error {
+	b.wg.Wait()
+	b.cancelContext()
+	if b.IsEmpty() {
+		return nil
+	}
+	return b
+}
+
+// WaitContext works like Wait, but it stops waiting when the ctx is done: then the context returned by WithContext
+// (if any) is cancelled, so that the goroutines abort, and a snapshot of the box (see Snapshot) with the cause
+// of the ctx (see context.Cause) appended is returned. The goroutines may still push errors into the box afterwards.
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	err := box.WaitContext(ctx)
+func (b *Box) WaitContext(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.wg.Wait()
+	}()
+	select {
+	case <-done:
+		return b.Wait()
+	case <-ctx.Done():
+		b.cancelContext()
+		return Append(b.Snapshot(), Annotate(context.Cause(ctx), "waiting for goroutines"))
+	}
+}
+
+// cancelContext cancels the context returned by WithContext, if any.
+func (b *Box) cancelContext() {
+	b.mu.Lock()
+	cancel := b.cancel
+	b.mu.Unlock()
+	if cancel != nil {
+		cancel(context.Canceled)
+	}
+}
+
+// pushErr pushes the err into the box under lock. If the err is a *Box, its errors are pushed instead.
+// Nil err is ignored, and so is an err which contains the box (see isIn).
+func (b *Box) pushErr(err error) {
+	if isNil(err) || b.isIn(err) {
+		return
+	}
+	var errs []*StackErr
+	if eb, ok := err.(*Box); ok {
+		eb.mu.Lock()
+		errs = append(errs, eb.errLis...)
+		eb.mu.Unlock()
+	} else {
+		errs = append(errs, WithStack(err))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range errs {
+		b.push(e)
+	}
+}
+
+// Collect drains the channel into a new box, until the channel is closed or the ctx is done, and returns the box.
+// Every error received from the channel is converted by WithStack, nil errors are ignored.
+//
+// Worker pools which funnel errors through a channel can use it like so:
+//
+//	errs := make(chan error)
+//	go runWorkers(errs) // closes errs when all workers are done
+//	box := errbox.Collect(ctx, errs)
+//
+// If the ctx is done before the channel is closed, errors which were not received yet are not in the box;
+// check ctx.Err() if you need to know.
+func Collect(ctx context.Context, ch <-chan error) *Box {
+	b := NewBox()
+	for {
+		select {
+		case <-ctx.Done():
+			return b
+		case err, ok := <-ch:
+			if !ok {
+				return b
+			}
+			b.pushErr(err)
+		}
+	}
+}
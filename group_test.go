@@ -0,0 +1,152 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoxGo(t *testing.T) {
+	sentinel := fmt.Errorf("boom")
+	var b Box
+	for i := 0; i < 10; i++ {
+		i := i
+		b.Go(func() error {
+			switch {
+			case i%3 == 0:
+				return sentinel
+			case i == 5:
+				panic("five")
+			}
+			return nil
+		})
+	}
+	err := b.Wait()
+	if b.Len() != 5 {
+		t.Errorf("expected 5 errors, got %d: %v", b.Len(), err)
+	}
+	if !IsInside(err, sentinel) || !IsInside(err, ErrPanic) {
+		t.Errorf("expected both returned error and panic in the box: %v", err)
+	}
+
+	var empty Box
+	empty.Go(func() error { return nil })
+	if err := empty.Wait(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestBoxGoOrigin(t *testing.T) {
+	ShowStack(true)
+	var b Box
+	b.Go(func() error { return errors.New("boom") })
+	err := b.Wait()
+	if err == nil || !strings.Contains(err.Error(), "launched from:") || !strings.Contains(err.Error(), "(TestBoxGoOrigin)") {
+		t.Errorf("expected origin in the error: %v", err)
+	}
+}
+
+func TestBoxSetLimit(t *testing.T) {
+	var (
+		b              Box
+		active, peaked int32
+	)
+	b.SetLimit(2)
+	for i := 0; i < 20; i++ {
+		b.Go(func() error {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				p := atomic.LoadInt32(&peaked)
+				if n <= p || atomic.CompareAndSwapInt32(&peaked, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return fmt.Errorf("boom")
+		})
+	}
+	if err := b.Wait(); err == nil || b.Len() != 20 {
+		t.Errorf("expected all 20 errors, got %d", b.Len())
+	}
+	if peaked > 2 {
+		t.Errorf("expected at most 2 active goroutines, got %d", peaked)
+	}
+}
+
+func TestBoxWithContext(t *testing.T) {
+	fatalErr := errors.New("fatal")
+	var b Box
+	ctx := b.WithContext(context.Background(), func(err error) bool { return errors.Is(err, fatalErr) })
+
+	b.PushIf(errors.New("not fatal"), "")
+	if ctx.Err() != nil {
+		t.Fatalf("context should not be cancelled yet")
+	}
+	b.Go(func() error { return fatalErr })
+	b.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	b.Wait()
+	if !errors.Is(context.Cause(ctx), fatalErr) {
+		t.Errorf("expected the fatal error to cancel the context, got %v", context.Cause(ctx))
+	}
+	if b.Len() != 3 {
+		t.Errorf("expected partial failures to be collected, got %d errors", b.Len())
+	}
+}
+
+func TestBoxWaitCancelsContext(t *testing.T) {
+	var b Box
+	ctx := b.WithContext(context.Background(), nil)
+	b.Go(func() error { return nil })
+	if err := b.Wait(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Errorf("expected the context to be done after Wait")
+	}
+
+	var slow Box
+	ctx = slow.WithContext(context.Background(), nil)
+	release := make(chan struct{})
+	defer close(release)
+	slow.Go(func() error {
+		<-release
+		return nil
+	})
+	waitCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := slow.WaitContext(waitCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cause of the context, got %v", err)
+	}
+	if ctx.Err() == nil || !slow.IsEmpty() {
+		t.Errorf("expected the context to be cancelled, and the box not to be changed")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ch := make(chan error)
+	go func() {
+		defer close(ch)
+		ch <- errors.New("one")
+		ch <- nil
+		ch <- errors.New("two")
+	}()
+	if b := Collect(context.Background(), ch); b.Len() != 2 {
+		t.Errorf("expected 2 errors, got %d", b.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if b := Collect(ctx, make(chan error)); !b.IsEmpty() {
+		t.Errorf("expected empty box, got %v", b)
+	}
+}
@@ -0,0 +1,80 @@
+package errbox
+
+import (
+	"net/http"
+	"sync"
+)
+
+// kindHTTPStatus maps kinds to HTTP status codes, see HTTPStatus.
+var kindHTTPStatus = map[Kind]int{
+	KindUnknown:          http.StatusInternalServerError,
+	KindNotFound:         http.StatusNotFound,
+	KindInvalid:          http.StatusBadRequest,
+	KindAlreadyExists:    http.StatusConflict,
+	KindPermissionDenied: http.StatusForbidden,
+	KindUnauthenticated:  http.StatusUnauthorized,
+	KindUnavailable:      http.StatusServiceUnavailable,
+	KindInternal:         http.StatusInternalServerError,
+}
+
+var (
+	// codeHTTPStatus holds mappings registered by RegisterHTTPStatus, protected by codeHTTPStatusMu.
+	codeHTTPStatus   = make(map[string]int)
+	codeHTTPStatusMu sync.RWMutex
+)
+
+// RegisterHTTPStatus registers the HTTP status for errors with the code (see WithCode). Registered codes
+// take precedence over kinds of errors (see KindOf) in HTTPStatus. It is meant to be called from init functions,
+// but it is safe for concurrent use. Registering the same code again replaces the status.
+//
+//	func init() {
+//		errbox.RegisterHTTPStatus("QUOTA_EXCEEDED", http.StatusTooManyRequests)
+//	}
+func RegisterHTTPStatus(code string, status int) {
+	codeHTTPStatusMu.Lock()
+	defer codeHTTPStatusMu.Unlock()
+	codeHTTPStatus[code] = status
+}
+
+// HTTPStatus returns the HTTP status code which should be sent to the client because of the err,
+// so that HTTP handlers translate errors consistently:
+//
+//   - http.StatusOK is returned if the err is nil,
+//   - the status registered for the code of the err is returned (see RegisterHTTPStatus and CodeOf),
+//   - otherwise the status is derived from the kind of the err (see KindOf); uncategorized errors
+//     are http.StatusInternalServerError.
+//
+// When the err is a *Box, or it wraps one (like a group, see Box.Group), the most severe (highest) status of all
+// errors in the box is returned.
+func HTTPStatus(err error) int {
+	if isNil(err) {
+		return http.StatusOK
+	}
+	if sb, ok := err.(*ShardedBox); ok {
+		err = sb.Box() // a snapshot of all shards
+	}
+	if b := boxIn(err); b != nil && (b == err || !b.IsEmpty()) {
+		status := 0
+		for _, e := range Errors(b) {
+			if s := HTTPStatus(e); s > status {
+				status = s
+			}
+		}
+		if status == 0 {
+			return http.StatusOK
+		}
+		return status
+	}
+	if code := CodeOf(err); code != "" {
+		codeHTTPStatusMu.RLock()
+		status, ok := codeHTTPStatus[code]
+		codeHTTPStatusMu.RUnlock()
+		if ok {
+			return status
+		}
+	}
+	if status, ok := kindHTTPStatus[KindOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,40 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	RegisterHTTPStatus("QUOTA_EXCEEDED", http.StatusTooManyRequests)
+
+	mixed := NewBox()
+	mixed.PushIf(NotFound("user"), "")
+	mixed.PushIf(Internal("database"), "")
+	grouped := NewBox()
+	grouped.AddGroup("users", mixed)
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("boom"), http.StatusInternalServerError},
+		{NotFound("user %d", 1), http.StatusNotFound},
+		{Annotate(Invalid("bad name"), "creating user"), http.StatusBadRequest},
+		{WithCode(Invalid("too many requests"), "QUOTA_EXCEEDED"), http.StatusTooManyRequests},
+		{WithCode(NotFound("user"), "NOT_REGISTERED"), http.StatusNotFound},
+		{Append(Append(nil, NotFound("a")), Unavailable("b")), http.StatusServiceUnavailable},
+		{NewBox(), http.StatusOK},
+		{grouped, http.StatusInternalServerError},
+		{fmt.Errorf("loading: %w", mixed), http.StatusInternalServerError},
+		{fmt.Errorf("loading: %w", Append(nil, NotFound("user"))), http.StatusNotFound},
+	}
+	for i, tt := range tests {
+		if got := HTTPStatus(tt.err); got != tt.want {
+			t.Errorf("#%d: expected %d, got %d", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package errbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Record is a structured representation of a StackErr or a Box, with exported fields. It is the schema of
+// the JSON encoding (see StackErr.MarshalJSON), and integrations which encode errors in other formats (like
+// protocol buffers) map it field by field, instead of depending on the internals of errors. See ToRecord
+// and FromRecord.
+//
+// A record of a single error has the Cause set. A record of a box has neither the Cause, nor the Group set,
+// and its errors are in Errors. A record of a named group of a box (see Box.Group) has the Group set.
+type Record struct {
+	Cause       *string                `json:"cause,omitempty"` // message of the cause, nil for boxes and groups
+	Group       string                 `json:"group,omitempty"`
+	Errors      []*Record              `json:"errors,omitempty"`  // errors of a box, or of a group
+	Dropped     int                    `json:"dropped,omitempty"` // errors dropped by a box with capacity
+	Annotations []Annotation           `json:"annotations,omitempty"`
+	Code        string                 `json:"code,omitempty"`
+	Kind        string                 `json:"kind,omitempty"`
+	Severity    string                 `json:"severity,omitempty"`
+	Retryable   *bool                  `json:"retryable,omitempty"`
+	UserMessage string                 `json:"user_message,omitempty"`
+	Hints       []string               `json:"hints,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	Suppressed  []*Record              `json:"suppressed,omitempty"`
+	Service     string                 `json:"service,omitempty"` // service where the error happened
+	Remote      *Record                `json:"remote,omitempty"`  // the cause, if it is a RemoteErr
+}
+
+// ToRecord returns the record of the err. Errors which are neither *StackErr, nor *Box are converted
+// by WithStack first. Nil is returned if the err is nil.
+func ToRecord(err error) *Record {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		return b.toRecord()
+	}
+	return Inspect(err).toRecord()
+}
+
+// FromRecord reconstructs the error from the record, see FromJSON. Codes are not validated, even if the
+// StrictCodes option is set (see RestoreCode). Nil is returned if the r is nil.
+func FromRecord(r *Record) error {
+	if r == nil {
+		return nil
+	}
+	if r.Cause == nil && r.Group == "" {
+		return r.toBox()
+	}
+	return r.toStackErr()
+}
+
+// MarshalJSON implements json.Marshaler. The error is encoded as an object with the cause message, annotations
+// (with places in code), and classification of the error (code, kind, severity, ...), fields, tags and suppressed
+// errors. The error can be reconstructed by FromJSON.
+//
+// If the ServiceName option is set, the error is marked by the name, and FromJSON reconstructs it
+// as a RemoteErr.
+func (b *StackErr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.toRecord())
+}
+
+// MarshalJSON implements json.Marshaler. The box is encoded as an object with the list of its errors,
+// see StackErr.MarshalJSON. The box can be reconstructed by FromJSON.
+func (b *Box) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.toRecord())
+}
+
+// toRecord returns the record of the box.
+func (b *Box) toRecord() *Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	je := &Record{Dropped: b.dropped}
+	for _, err := range b.entries() {
+		je.Errors = append(je.Errors, err.toRecord())
+	}
+	return je
+}
+
+// toRecord returns the record of the error.
+func (b *StackErr) toRecord() *Record {
+	if b.group != "" {
+		je := b.cause.(*Box).toRecord()
+		je.Group = b.group
+		return je
+	}
+	cause := b.cause.Error()
+	je := &Record{Cause: &cause, Service: currentOptions().ServiceName}
+	if r, ok := b.cause.(*RemoteErr); ok {
+		je.Remote = r.toRecord()
+	}
+	je.Annotations = b.Annotations()
+	je.Code, _ = b.ownCode()
+	if kind, ok := b.ownKind(); ok {
+		je.Kind = kind.String()
+	}
+	if severity, ok := b.ownSeverity(); ok {
+		je.Severity = severity.String()
+	}
+	if retryable, ok := b.ownRetryable(); ok {
+		je.Retryable = &retryable
+	}
+	b.mu.Lock()
+	je.UserMessage = b.userMessage
+	b.mu.Unlock()
+	je.Hints = b.ownHints()
+	je.Tags = b.Tags()
+	je.Fields = b.CopyFields()
+	for _, err := range b.Suppressed() {
+		if se, ok := err.(*StackErr); ok {
+			je.Suppressed = append(je.Suppressed, se.toRecord())
+		} else {
+			cause := err.Error()
+			je.Suppressed = append(je.Suppressed, &Record{Cause: &cause})
+		}
+	}
+	return je
+}
+
+// FromJSON reconstructs the error encoded by MarshalJSON of StackErr or Box, so that errors serialized by one
+// service can be re-wrapped and re-reported by another. The first return value is the reconstructed error
+// (nil, if the data is JSON null), the second one is an error which happened while the data was decoded.
+//
+// Causes of reconstructed errors are plain errors with the original message, annotations keep places in code
+// where they were made in the original process. Numbers in fields are decoded as float64 (see json.Unmarshal).
+// Errors encoded while the ServiceName option was set are reconstructed as a StackErr with a RemoteErr cause.
+func FromJSON(data []byte) (error, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return FromRecord(&r), nil
+}
+
+// toBox reconstructs the box from its JSON representation.
+func (je *Record) toBox() *Box {
+	b := NewBox()
+	je.decodeBox(b)
+	return b
+}
+
+// decodeBox appends errors of the box in JSON representation to the b. Nil records are skipped.
+func (je *Record) decodeBox(b *Box) {
+	for _, e := range je.Errors {
+		if e != nil {
+			b.push(e.toStackErr())
+		}
+	}
+	b.dropped += je.Dropped
+}
+
+// toStackErr reconstructs the error from its JSON representation.
+func (je *Record) toStackErr() *StackErr {
+	b := new(StackErr)
+	je.decodeStackErr(b)
+	return b
+}
+
+// decodeStackErr sets the b to the error in JSON representation. The b must not be used by anyone else yet.
+func (je *Record) decodeStackErr(b *StackErr) {
+	if je.Cause == nil {
+		b.cause, b.group = je.toBox(), groupName(je.Group)
+		return
+	}
+	if je.Service != "" {
+		remote := *je
+		remote.Service = ""
+		b.cause = &RemoteErr{Service: je.Service, Code: je.Code, err: remote.toStackErr()}
+		return
+	}
+	if je.Remote != nil {
+		b.cause = je.Remote.toStackErr().cause
+	} else {
+		b.cause = errors.New(*je.Cause)
+	}
+	b.code = je.Code
+	b.kind = kindByName(je.Kind)
+	b.severity = severityByName(je.Severity)
+	b.userMessage = je.UserMessage
+	b.hints = je.Hints
+	b.tags = je.Tags
+	b.fields = je.Fields
+	for _, anno := range je.Annotations {
+		b.annotation = append(b.annotation, stackAnnotation{
+			message:  anno.Message,
+			file:     anno.File,
+			line:     anno.Line,
+			function: anno.Function,
+			repeated: anno.Repeated,
+		})
+	}
+	if je.Retryable != nil {
+		b.retryable = toggleOf(*je.Retryable)
+	}
+	for _, s := range je.Suppressed {
+		if s != nil {
+			b.suppressed = append(b.suppressed, s.toStackErr())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package errbox
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	err := WithCode(NotFound("user %d", 42), "USER_NOT_FOUND")
+	err = MarkRetryable(WithField(Annotate(err, "loading profile"), "user_id", 42))
+	box := NewBox()
+	box.PushIf(err, "")
+	box.Group("config").PushIf(WithSeverity(errors.New("deprecated key"), SeverityWarning), "parsing")
+
+	data, jerr := json.Marshal(box)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	got, jerr := FromJSON(data)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	if got.Error() != box.Error() {
+		t.Errorf("expected the same rendering, got:\n%s\nwant:\n%s", got, box)
+	}
+	if CodeOf(got) != "USER_NOT_FOUND" || KindOf(got) != KindNotFound || !IsRetryable(Errors(got)[0]) {
+		t.Errorf("classification was lost: %s", data)
+	}
+	if id, _ := Field[float64](got, "user_id"); id != 42 {
+		t.Errorf("field was lost: %s", data)
+	}
+	if s := SeverityOf(got); s != SeverityError {
+		t.Errorf("expected error severity, got %v", s)
+	}
+
+	if got, jerr := FromJSON([]byte("null")); got != nil || jerr != nil {
+		t.Errorf("expected nil, got %v, %v", got, jerr)
+	}
+	if _, jerr := FromJSON([]byte("{")); jerr == nil {
+		t.Errorf("expected decoding error")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	if ToRecord(nil) != nil || FromRecord(nil) != nil {
+		t.Errorf("expected nil")
+	}
+	box := NewBox()
+	box.PushIf(WithCode(errors.New("boom"), "E1"), "loading")
+	box.Group("config").PushIf(errors.New("unknown key"), "")
+
+	r := ToRecord(box)
+	if r.Cause != nil || len(r.Errors) != 2 || r.Errors[0].Code != "E1" || r.Errors[1].Group != "config" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if a := r.Errors[0].Annotations; len(a) != 1 || a[0].Message != "loading" || a[0].Line == 0 {
+		t.Errorf("unexpected annotations %+v", a)
+	}
+	if got := FromRecord(r); got.Error() != box.Error() {
+		t.Errorf("expected the same rendering, got:\n%s\nwant:\n%s", got, box)
+	}
+}
+
+func TestFromJSONNullRecords(t *testing.T) {
+	err, decodeErr := FromJSON([]byte(`{"errors":[null,{"cause":"x"}]}`))
+	if decodeErr != nil || len(Errors(err)) != 1 {
+		t.Errorf("expected null errors to be skipped, got %v, %v", err, decodeErr)
+	}
+	err, decodeErr = FromJSON([]byte(`{"cause":"x","suppressed":[null]}`))
+	if decodeErr != nil || Cause(err).Error() != "x" || len(WithStack(err).Suppressed()) != 0 {
+		t.Errorf("expected null suppressed errors to be skipped, got %v, %v", err, decodeErr)
+	}
+}
@@ -0,0 +1,203 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Kind is the category of the error, see KindOf. Services can branch on the category, instead of matching
+// error messages.
+type Kind int
+
+const (
+	// KindUnknown is the kind of errors which were not categorized.
+	KindUnknown Kind = iota
+	// KindNotFound means that the requested entity does not exist.
+	KindNotFound
+	// KindInvalid means that the input was not valid.
+	KindInvalid
+	// KindAlreadyExists means that the entity which should be created already exists.
+	KindAlreadyExists
+	// KindPermissionDenied means that the caller is not allowed to do the operation.
+	KindPermissionDenied
+	// KindUnauthenticated means that the caller is not known.
+	KindUnauthenticated
+	// KindUnavailable means that a dependency is (typically, temporarily) not available.
+	KindUnavailable
+	// KindInternal means that an invariant of the program was broken.
+	KindInternal
+)
+
+var kindNames = [...]string{
+	KindUnknown:          "unknown",
+	KindNotFound:         "not found",
+	KindInvalid:          "invalid",
+	KindAlreadyExists:    "already exists",
+	KindPermissionDenied: "permission denied",
+	KindUnauthenticated:  "unauthenticated",
+	KindUnavailable:      "unavailable",
+	KindInternal:         "internal",
+}
+
+// String returns the name of the kind, like "not found".
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// kindByName returns the kind with the name (see Kind.String), or KindUnknown.
+func kindByName(name string) Kind {
+	for k, n := range kindNames {
+		if n == name {
+			return Kind(k)
+		}
+	}
+	return KindUnknown
+}
+
+// NotFound returns a new error of the KindNotFound. The message is formatted by fmt.Errorf,
+// so it can wrap another error using the %w verb:
+//
+//	return errbox.NotFound("user %d", id)
+//
+// Without args, the message is used as it is (see Annotate), so messages like "disk 95% full" are not mangled.
+func NotFound(message string, args ...interface{}) error {
+	return newKind(KindNotFound, message, args...)
+}
+
+// Invalid returns a new error of the KindInvalid, see NotFound.
+func Invalid(message string, args ...interface{}) error {
+	return newKind(KindInvalid, message, args...)
+}
+
+// AlreadyExists returns a new error of the KindAlreadyExists, see NotFound.
+func AlreadyExists(message string, args ...interface{}) error {
+	return newKind(KindAlreadyExists, message, args...)
+}
+
+// PermissionDenied returns a new error of the KindPermissionDenied, see NotFound.
+func PermissionDenied(message string, args ...interface{}) error {
+	return newKind(KindPermissionDenied, message, args...)
+}
+
+// Unauthenticated returns a new error of the KindUnauthenticated, see NotFound.
+func Unauthenticated(message string, args ...interface{}) error {
+	return newKind(KindUnauthenticated, message, args...)
+}
+
+// Unavailable returns a new error of the KindUnavailable, see NotFound.
+func Unavailable(message string, args ...interface{}) error {
+	return newKind(KindUnavailable, message, args...)
+}
+
+// Internal returns a new error of the KindInternal, see NotFound.
+func Internal(message string, args ...interface{}) error {
+	return newKind(KindInternal, message, args...)
+}
+
+// newKind implements constructors of kinds; the error is annotated with the place where the constructor was called.
+func newKind(kind Kind, message string, args ...interface{}) error {
+	cause := errors.New(message)
+	if len(args) > 0 {
+		cause = fmt.Errorf(message, args...)
+	}
+	be, _ := withStack(cause)
+	be.kind = kind
+	be.annotate(3, "")
+	created(be)
+	return be
+}
+
+// WithKind returns the err (as *StackErr) with the kind set, or nil, if the err is nil. It is meant
+// for categorizing errors which come from other packages:
+//
+//	if errors.Is(err, sql.ErrNoRows) {
+//		return errbox.WithKind(err, errbox.KindNotFound)
+//	}
+//
+// Call of WithKind on error which is a *Box sets the kind on all errors in the box.
+func WithKind(err error, kind Kind) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			WithKind(e, kind)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.kind = kind
+	return be
+}
+
+// KindOf returns the kind of the err, or of any error it wraps, or of any error in a box found along the way
+// (the outermost kind wins; in a box, the first error which has the kind wins).
+// If no kind was set, classifiers registered by RegisterClassifier are asked.
+// KindUnknown is returned if the error was not categorized.
+func KindOf(err error) Kind {
+	if kind, ok := lookup(err, (*StackErr).ownKind); ok {
+		return kind
+	}
+	return classify(err)
+}
+
+var (
+	// classifiers holds functions registered by RegisterClassifier, protected by classifiersMu.
+	classifiers   []func(error) (Kind, bool)
+	classifiersMu sync.RWMutex
+)
+
+// RegisterClassifier registers the fn, which teaches KindOf (and so HTTPStatus, IsRetryable, ...) how to
+// categorize errors which come from other packages, and which do not have a kind set by WithKind:
+//
+//	errbox.RegisterClassifier(func(err error) (errbox.Kind, bool) {
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return errbox.KindNotFound, true
+//		}
+//		return errbox.KindUnknown, false
+//	})
+//
+// Classifiers are asked in order of registration, and the first one which returns true wins. They are called
+// with the error itself (use errors.Is and errors.As to inspect errors it wraps), for a *Box they are called
+// with every error in the box. It is meant to be called from init functions, but it is safe for concurrent use.
+func RegisterClassifier(fn func(err error) (Kind, bool)) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	classifiers = append(classifiers, fn)
+}
+
+// classify returns the kind of the err according to registered classifiers, or KindUnknown.
+func classify(err error) Kind {
+	if isNil(err) {
+		return KindUnknown
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			if kind := classify(e); kind != KindUnknown {
+				return kind
+			}
+		}
+		return KindUnknown
+	}
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+	for _, fn := range classifiers {
+		if kind, ok := fn(err); ok {
+			return kind
+		}
+	}
+	return KindUnknown
+}
+
+// ownKind returns the kind set directly on the error, and true if it was set.
+func (b *StackErr) ownKind() (Kind, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.kind, b.kind != KindUnknown
+}
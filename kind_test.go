@@ -0,0 +1,61 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	err := NotFound("user %d", 42)
+	if k := KindOf(fmt.Errorf("handler: %w", Annotate(err, "loading profile"))); k != KindNotFound {
+		t.Errorf("expected not found, got %v", k)
+	}
+	if s := err.Error(); !strings.HasPrefix(s, "user 42\n") || !strings.Contains(s, "kind_test.go") {
+		t.Errorf("expected the error to point to the caller:\n%s", s)
+	}
+	if err := Invalid("reading config: %w", io.EOF); !errors.Is(err, io.EOF) {
+		t.Errorf("expected the cause to be wrapped")
+	}
+	message := "disk 95% full"
+	if s := Cause(NotFound(message)).Error(); s != message {
+		t.Errorf("expected the message without args to be kept, got %q", s)
+	}
+	if k := KindOf(WithKind(io.EOF, KindUnavailable)); k != KindUnavailable {
+		t.Errorf("expected unavailable, got %v", k)
+	}
+	if k := KindOf(io.EOF); k != KindUnknown {
+		t.Errorf("expected unknown, got %v", k)
+	}
+}
+
+type throttledErr struct{}
+
+func (throttledErr) Error() string { return "throttled" }
+
+func TestRegisterClassifier(t *testing.T) {
+	RegisterClassifier(func(err error) (Kind, bool) {
+		var te throttledErr
+		if errors.As(err, &te) {
+			return KindUnavailable, true
+		}
+		return KindUnknown, false
+	})
+
+	err := Annotate(fmt.Errorf("calling api: %w", throttledErr{}), "syncing")
+	if k := KindOf(err); k != KindUnavailable {
+		t.Errorf("expected unavailable, got %v", k)
+	}
+	if !IsRetryable(err) || HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("expected classified error to be retryable, and unavailable")
+	}
+	if k := KindOf(WithKind(throttledErr{}, KindInternal)); k != KindInternal {
+		t.Errorf("expected explicit kind to win, got %v", k)
+	}
+	if k := KindOf(Append(Append(nil, io.EOF), throttledErr{})); k != KindUnavailable {
+		t.Errorf("expected box to be classified, got %v", k)
+	}
+}
@@ -0,0 +1,80 @@
+package errbox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys used by LogrusFields. Fields attached to the error never override them.
+const (
+	LogrusKeyError       = "error"             // cause of the error
+	LogrusKeyAnnotations = "error_annotations" // annotation messages, from the deepest one
+	LogrusKeyCaller      = "error_caller"      // the deepest call site, as file:line (function)
+	LogrusKeyCount       = "error_count"       // number of errors, only set for a *Box
+)
+
+// LogrusFields flattens the err into a map, which can be passed directly to logrus (logrus.Fields is a map of
+// the same type, so no conversion is needed), for teams still on logrus:
+//
+//	log.WithFields(errbox.LogrusFields(err)).Error("failed")
+//
+// The map contains the cause, annotation messages, the deepest call site (omitted if the stack trace would not
+// be printed out) and all fields attached to the error. For a *Box, causes are joined, annotations and call site
+// are taken from the first error, and fields are merged (see Box.Fields with FirstWins).
+//
+// Returns nil if the err is nil.
+func LogrusFields(err error) map[string]interface{} {
+	if isNil(err) {
+		return nil
+	}
+
+	var (
+		first  *StackErr
+		fields map[string]interface{}
+		causes []string
+		count  = -1
+	)
+	if b, ok := err.(*Box); ok {
+		errs := Errors(b)
+		if len(errs) == 0 {
+			return nil
+		}
+		for _, e := range errs {
+			causes = append(causes, WithStack(e).cause.Error())
+		}
+		first = WithStack(errs[0])
+		fields = b.Fields(FirstWins)
+		count = len(errs)
+	} else {
+		first = Inspect(err)
+		fields = first.CopyFields()
+		causes = []string{first.cause.Error()}
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+
+	fields[LogrusKeyError] = strings.Join(causes, "; ")
+	if count >= 0 {
+		fields[LogrusKeyCount] = count
+	}
+	annotation := first.annotations()
+	var messages []string
+	for _, anno := range annotation {
+		if message := anno.text(); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) > 0 {
+		fields[LogrusKeyAnnotations] = messages
+	}
+	if first.stack.resolve(!currentOptions().HideStack) {
+		for _, anno := range annotation {
+			if anno.line > 0 {
+				fields[LogrusKeyCaller] = fmt.Sprintf("%s:%d (%s)", anno.file, anno.line, anno.function)
+				break
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,28 @@
+package errbox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLogrusFields(t *testing.T) {
+	ShowStack(true)
+
+	err := WithField(Annotate(fmt.Errorf("boom"), "processing order"), "order_id", 42)
+	f := LogrusFields(err)
+	if f[LogrusKeyError] != "boom" || f["order_id"] != 42 || len(f[LogrusKeyAnnotations].([]string)) != 1 {
+		t.Errorf("unexpected fields: %#v", f)
+	}
+	if c, _ := f[LogrusKeyCaller].(string); !strings.Contains(c, "(TestLogrusFields)") {
+		t.Errorf("unexpected caller: %#v", f)
+	}
+
+	f = LogrusFields(Append(err, fmt.Errorf("bang")))
+	if f[LogrusKeyError] != "boom; bang" || f[LogrusKeyCount] != 2 {
+		t.Errorf("unexpected fields: %#v", f)
+	}
+	if LogrusFields(nil) != nil {
+		t.Errorf("expected no fields for nil error")
+	}
+}
@@ -0,0 +1,91 @@
+package errbox
+
+// WithUserMessage returns the err (as *StackErr) with a message which is safe to show to the end user,
+// or nil, if the err is nil. The user message is kept apart from the annotations, so HTTP handlers can show
+// a friendly message, while logs keep the full annotated chain:
+//
+//	return errbox.WithUserMessage(err, "We couldn't process your upload")
+//
+// Call of WithUserMessage on error which is a *Box sets the message on all errors in the box.
+func WithUserMessage(err error, message string) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			WithUserMessage(e, message)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.userMessage = message
+	return be
+}
+
+// UserMessage returns the message set by WithUserMessage on the err, on any error it wraps, or on any error
+// in a box found along the way (the outermost message wins). Returns empty string if there is no user message,
+// the caller should then use a generic one.
+func UserMessage(err error) string {
+	msg, _ := lookup(err, func(se *StackErr) (string, bool) {
+		se.mu.Lock()
+		defer se.mu.Unlock()
+		return se.userMessage, se.userMessage != ""
+	})
+	return msg
+}
+
+// Hint returns the err (as *StackErr) with an actionable advice for the user, or nil, if the err is nil.
+// Hints are rendered in their own section when the error is printed out, so that CLI tools can surface
+// the advice separately from the failure narrative:
+//
+//	return errbox.Hint(err, "try re-running with --force")
+//
+// The message is formatting string used by fmt.Sprintf, like in Annotate.
+// Call of Hint on error which is a *Box adds the hint to all errors in the box.
+func Hint(err error, message string, args ...interface{}) error {
+	if isNil(err) {
+		return nil
+	}
+	hint := formatMessage(message, args)
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			Hint(e, "%s", hint)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.hints = append(be.hints, hint)
+	return be
+}
+
+// Hints returns all hints (see Hint) found on the err, on errors it wraps, and on errors in boxes, in order of Walk.
+// Every hint is returned only once.
+func Hints(err error) []string {
+	var (
+		hints []string
+		seen  = make(map[string]bool)
+	)
+	Walk(err, func(e error) bool {
+		if se, ok := e.(*StackErr); ok {
+			for _, h := range se.ownHints() {
+				if !seen[h] {
+					seen[h] = true
+					hints = append(hints, h)
+				}
+			}
+		}
+		return true
+	})
+	return hints
+}
+
+// ownHints returns a copy of hints attached directly to the error.
+func (b *StackErr) ownHints() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.hints...)
+}
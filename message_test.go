@@ -0,0 +1,37 @@
+package errbox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUserMessage(t *testing.T) {
+	err := WithUserMessage(fmt.Errorf("disk quota exceeded"), "We couldn't process your upload")
+	err = Annotate(fmt.Errorf("upload: %w", err), "handling request")
+
+	if m := UserMessage(err); m != "We couldn't process your upload" {
+		t.Errorf("unexpected user message: %q", m)
+	}
+	if strings.Contains(err.Error(), "We couldn't") {
+		t.Errorf("user message should not be part of the error: %s", err)
+	}
+	if m := UserMessage(fmt.Errorf("boom")); m != "" {
+		t.Errorf("expected no user message, got %q", m)
+	}
+}
+
+func TestHint(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+
+	err := Hint(fmt.Errorf("lock file exists"), "try re-running with %s", "--force")
+	err = Hint(Annotate(err, "acquiring lock"), "or remove the lock file")
+	want := "lock file exists\n +--> acquiring lock\n hint: try re-running with --force\n hint: or remove the lock file\n"
+	if s := err.Error(); s != want {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+	if h := Hints(Append(err, Hint(fmt.Errorf("other"), "or remove the lock file"))); len(h) != 2 {
+		t.Errorf("expected 2 distinct hints, got %q", h)
+	}
+}
@@ -0,0 +1,96 @@
+package errbox
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Observer is notified about errors, so that integrations (typically, metrics) can observe all errors handled
+// by errbox without touching every call site, see SetObserver.
+//
+// Methods of the observer are called synchronously, on hot paths, from any goroutine. They must be fast,
+// and they must NOT modify the error.
+type Observer interface {
+	// ErrorCreated is called when a new StackErr is created (see WithStack, Annotate, PushIf, and constructors
+	// like NotFound), which is typically where the error enters errbox. It is called once per error, with no box
+	// locked. Errors read by Inspect are not reported.
+	ErrorCreated(err *StackErr)
+	// ErrorPushed is called when the error is pushed into a box, see Box.OnPush. It is called with the box
+	// locked, therefore it must NOT call methods of the box.
+	ErrorPushed(err *StackErr)
+}
+
+// observers holds []Observer notified about errors: the one set by SetObserver (or nil) first, then hooks
+// registered by OnCreate. It is replaced as a whole under observersMu, readers do NOT need the lock.
+var (
+	observers   atomic.Value
+	observersMu sync.Mutex
+)
+
+func init() {
+	observers.Store([]Observer{nil})
+}
+
+// SetObserver sets the observer, which is notified about all errors, see Observer. Nil removes the observer.
+// It is safe to call it at any time, from any goroutine.
+func SetObserver(o Observer) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	current := currentObservers()
+	updated := append([]Observer{o}, current[1:]...)
+	observers.Store(updated)
+}
+
+// currentObservers returns observers which are notified about errors, the one set by SetObserver (or nil) first.
+// The returned slice must NOT be modified.
+func currentObservers() []Observer {
+	return observers.Load().([]Observer)
+}
+
+// OnCreate registers the fn, which is called whenever a new StackErr is created (see WithStack, Annotate, PushIf,
+// FromPanic, and constructors like NotFound), which is typically where the error enters errbox. Unlike Observer,
+// the fn may modify the error, so hooks can centrally attach request IDs, bump metrics, or sample traces:
+//
+//	errbox.OnCreate(func(err *errbox.StackErr) {
+//		err.SetField("host", hostname)
+//	})
+//
+// Hooks are called synchronously, after the Observer, in order of registration, from any goroutine, with no box
+// locked (so they may call methods of boxes); they must be fast. Hooks can not be removed; register them once,
+// at start up.
+func OnCreate(fn func(err *StackErr)) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	current := currentObservers()
+	updated := append(append([]Observer(nil), current...), createHook(fn))
+	observers.Store(updated)
+}
+
+// createHook is a hook registered by OnCreate, it is an Observer which is only notified about new errors.
+type createHook func(err *StackErr)
+
+// ErrorCreated implements Observer.
+func (fn createHook) ErrorCreated(err *StackErr) {
+	fn(err)
+}
+
+// ErrorPushed implements Observer, it does nothing.
+func (fn createHook) ErrorPushed(err *StackErr) {}
+
+// created notifies observers about the new error.
+func created(err *StackErr) {
+	for _, o := range currentObservers() {
+		if o != nil {
+			o.ErrorCreated(err)
+		}
+	}
+}
+
+// pushed notifies observers about the error pushed into a box.
+func pushed(err *StackErr) {
+	for _, o := range currentObservers() {
+		if o != nil {
+			o.ErrorPushed(err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package errbox
+
+import (
+	"errors"
+	"testing"
+)
+
+type countingObserver struct {
+	created, pushed int
+}
+
+func (o *countingObserver) ErrorCreated(err *StackErr) { o.created++ }
+func (o *countingObserver) ErrorPushed(err *StackErr)  { o.pushed++ }
+
+func TestObserver(t *testing.T) {
+	o := new(countingObserver)
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	err := Annotate(errors.New("boom"), "first")
+	Annotate(err, "second")
+	box := NewBox()
+	box.PushIf(err, "")
+	box.PushIf(errors.New("other"), "pushing")
+	if o.created != 2 || o.pushed != 2 {
+		t.Errorf("expected 2 created and 2 pushed errors, got %d and %d", o.created, o.pushed)
+	}
+
+	o.created = 0
+	WithStack(errors.New("with stack"))
+	Append(nil, errors.New("appended"))
+	FromPanic("panicked")
+	var group Box
+	group.Go(func() error { return errors.New("in goroutine") })
+	_ = group.Wait()
+	if o.created != 4 {
+		t.Errorf("expected 4 created errors, got %d", o.created)
+	}
+
+	// reading errors does not report them
+	o.created = 0
+	plain := errors.New("plain")
+	Inspect(plain)
+	SlogAttrs(plain)
+	ToRecord(plain)
+	Fingerprint(plain)
+	if o.created != 0 {
+		t.Errorf("expected no created errors, got %d", o.created)
+	}
+}
+
+func TestOnCreate(t *testing.T) {
+	var calls int
+	OnCreate(func(err *StackErr) {
+		if Cause(err).Error() == "on create" {
+			calls++
+			err.SetField("request_id", "r-42")
+		}
+	})
+
+	err := Annotate(errors.New("on create"), "first")
+	err = Annotate(err, "second")
+	box := NewBox()
+	box.PushIf(err, "")
+	if calls != 1 {
+		t.Errorf("expected the hook to be called once, got %d", calls)
+	}
+	if StringFieldOf(box, "request_id") != "r-42" {
+		t.Errorf("expected the field set by the hook")
+	}
+
+	// the hook may use the box the error is pushed into
+	var seen int
+	box = NewBox()
+	OnCreate(func(err *StackErr) {
+		if Cause(err).Error() == "uses the box" {
+			seen = box.Len()
+		}
+	})
+	box.PushIf(errors.New("uses the box"), "")
+	if seen != 0 || box.Len() != 1 {
+		t.Errorf("expected the hook to see the box before the push, got %d", seen)
+	}
+}
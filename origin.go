@@ -0,0 +1,111 @@
+package errbox
+
+import (
+	"runtime"
+	"strings"
+)
+
+// maxOriginFrames limits how many stack frames are captured by CaptureOrigin.
+const maxOriginFrames = 32
+
+// Origin is the stack of the goroutine which launched another goroutine. Errors produced inside the launched
+// goroutine do not have the launching code in their stack trace, Origin stitches it back (see Attach).
+type Origin struct {
+	frames []stackAnnotation
+}
+
+// CaptureOrigin captures stack of the calling goroutine, starting with the caller of CaptureOrigin.
+// It is meant to be called right before a goroutine is launched, and the result should be attached
+// to errors produced inside of the goroutine, like so:
+//
+//	origin := errbox.CaptureOrigin()
+//	go func() {
+//		results <- origin.Attach(work())
+//	}()
+//
+// See also Go, which does this for you.
+func CaptureOrigin() *Origin {
+	return captureOrigin(3)
+}
+
+// captureOrigin captures the stack, skip has the same meaning as in runtime.Callers.
+func captureOrigin(skip int) *Origin {
+	pcs := make([]uintptr, maxOriginFrames)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	o := new(Origin)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			o.frames = append(o.frames, stackAnnotation{
+				file:     cleanFile(frame.File),
+				line:     frame.Line,
+				function: shortFuncName(frame.Function),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return o
+}
+
+// Attach returns the err (as *StackErr) with the origin attached to it, or nil, if the err is nil.
+// When the error is printed out with the stack trace, the origin is printed in a "launched from" section.
+//
+// Call of Attach on error which is a *Box attaches the origin to all errors in the box.
+func (o *Origin) Attach(err error) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range b.leaves() {
+			e.origins = append(e.origins, o)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.origins = append(be.origins, o)
+	return be
+}
+
+// Go runs the fn in a new goroutine. Stack of the caller is captured before the goroutine is launched,
+// and attached to the error returned by the fn (see Origin), so that the trace shows both where the worker failed
+// and where it was launched.
+//
+// The error (or nil) is sent to the returned channel, which is then closed.
+func Go(fn func() error) <-chan error {
+	origin := captureOrigin(3)
+	ch := make(chan error, 1)
+	go func() {
+		defer close(ch)
+		ch <- origin.Attach(fn())
+	}()
+	return ch
+}
+
+// SafeGo runs the fn in a new goroutine, and calls the onErr with the error returned by the fn, if it is not nil.
+// Panics in the fn are recovered, and converted to errors with the full stack (see FromPanic). Like in Go,
+// the stack of the caller is attached to the error, so the trace shows where the goroutine was launched:
+//
+//	errbox.SafeGo(func() error {
+//		return refreshCache(ctx)
+//	}, func(err error) {
+//		log.Println(err)
+//	})
+//
+// The onErr is called in the new goroutine.
+func SafeGo(fn func() error, onErr func(err error)) {
+	origin := captureOrigin(3)
+	go func() {
+		var err error
+		defer func() {
+			if err != nil {
+				onErr(origin.Attach(err))
+			}
+		}()
+		defer Recover(&err)
+		err = fn()
+	}()
+}
@@ -0,0 +1,50 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGo(t *testing.T) {
+	ShowStack(true)
+
+	err := <-Go(func() error {
+		return Annotate(fmt.Errorf("boom"), "inside the worker")
+	})
+	s := err.Error()
+	if !strings.Contains(s, "launched from:") || !strings.Contains(s, "(TestGo)") {
+		t.Errorf("expected origin of the goroutine in the error: %s", s)
+	}
+
+	if err := <-Go(func() error { return nil }); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	errs := make(chan error, 2)
+	SafeGo(func() error { return errors.New("boom") }, func(err error) { errs <- err })
+	SafeGo(func() error { panic("bang") }, func(err error) { errs <- err })
+	SafeGo(func() error { return nil }, func(err error) { errs <- err })
+
+	var panicked, failed bool
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		if !strings.Contains(err.Error(), "launched from:") || !strings.Contains(err.Error(), "TestSafeGo") {
+			t.Errorf("expected origin in the error:\n%s", err)
+		}
+		panicked = panicked || errors.Is(err, ErrPanic)
+		failed = failed || Cause(err).Error() == "boom"
+	}
+	if !panicked || !failed {
+		t.Errorf("expected both the error and the panic to be delivered")
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("unexpected error %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
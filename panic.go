@@ -0,0 +1,77 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanic is the sentinel for errors created from a recovered panic. Use errors.Is(err, ErrPanic) to check for it.
+var ErrPanic = errors.New("panic")
+
+// panicErr is the cause of errors created from a recovered panic.
+type panicErr struct {
+	value interface{} // the value passed to panic
+}
+
+// Error implements the error interface.
+func (p *panicErr) Error() string {
+	return fmt.Sprintf("panic: %v", p.value)
+}
+
+// Unwrap returns the value passed to panic, if it was an error.
+func (p *panicErr) Unwrap() error {
+	if err, ok := p.value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Is makes the error equal to ErrPanic.
+func (p *panicErr) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// FromPanic converts the value returned by recover() to a *StackErr, which carries the full goroutine stack
+// at the time of the panic. Returns nil if recovered is nil.
+//
+// FromPanic must be called from the deferred function which recovered the panic, otherwise the stack does not
+// contain the place where the panic happened. Typically, you would use it as follows:
+//
+//	defer func() {
+//		if err := errbox.FromPanic(recover()); err != nil {
+//			log.Println(err)
+//		}
+//	}()
+//
+// The error matches ErrPanic, and if the panic value was an error, it matches that error as well (see errors.Is).
+func FromPanic(recovered interface{}) *StackErr {
+	if recovered == nil {
+		return nil
+	}
+	be, _ := withStack(&panicErr{value: recovered})
+	be.panicStack = debug.Stack()
+	created(be)
+	return be
+}
+
+// Recover recovers from a panic, and stores it (see FromPanic) to the err. It must be deferred directly, like so:
+//
+//	func doSomething() (err error) {
+//		defer errbox.Recover(&err)
+//		// ...
+//	}
+//
+// If the err already contains an error, the panic is appended to it (see Append).
+// Recover does nothing if there was no panic.
+func Recover(err *error) {
+	pe := FromPanic(recover())
+	if pe == nil {
+		return
+	}
+	if isNil(*err) {
+		*err = pe
+		return
+	}
+	*err = Append(*err, pe)
+}
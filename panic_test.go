@@ -0,0 +1,36 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	ShowStack(true)
+	sentinel := fmt.Errorf("kaboom")
+
+	panicking := func() (err error) {
+		defer Recover(&err)
+		panic(sentinel)
+	}
+	err := panicking()
+	if !errors.Is(err, ErrPanic) || !errors.Is(err, sentinel) {
+		t.Errorf("expected panic error wrapping the sentinel, got: %v", err)
+	}
+	if s := err.Error(); !strings.Contains(s, "panic stack:") || !strings.Contains(s, "TestRecover") {
+		t.Errorf("expected goroutine stack in the error: %s", s)
+	}
+
+	calm := func() (err error) {
+		defer Recover(&err)
+		return nil
+	}
+	if err := calm(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if FromPanic(nil) != nil {
+		t.Errorf("expected nil from FromPanic(nil)")
+	}
+}
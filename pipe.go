@@ -0,0 +1,74 @@
+package errbox
+
+// Pipe is a chain of steps, which pass a value of the type T from one to the next, up to the point when one
+// of them fails. It is a counterpart of Run and Then for steps which consume and produce values:
+//
+//	cfg, err := errbox.StartPipe(loadConfig).
+//		Then(applyDefaults).
+//		Then(validate).
+//		Result()
+//
+// Steps which change the type of the value can be added by PipeTo.
+type Pipe[T any] struct {
+	value T
+	err   error
+	step  int // number of steps which ran so far
+}
+
+// StartPipe runs the fn, and returns a pipe with its result. See Pipe.
+func StartPipe[T any](fn func() (T, error)) *Pipe[T] {
+	p := &Pipe[T]{step: 1}
+	value, err := fn()
+	if err != nil {
+		p.err = annotateErr(3, err, "pipe step %d", p.step)
+		return p
+	}
+	p.value = value
+	return p
+}
+
+// PipeValue returns a pipe which starts with the value. See Pipe.
+func PipeValue[T any](value T) *Pipe[T] {
+	return &Pipe[T]{value: value}
+}
+
+// Then runs the fn with the value produced by the previous step, if and only if no step failed so far.
+// If the fn returns an error, the error is annotated by the number of the step (counted from 1) and the place
+// where Then was called, and the remaining steps are skipped.
+func (p *Pipe[T]) Then(fn func(value T) (T, error)) *Pipe[T] {
+	if p.err != nil {
+		return p
+	}
+	p.step++
+	value, err := fn(p.value)
+	if err != nil {
+		var zero T
+		p.value, p.err = zero, annotateErr(3, err, "pipe step %d", p.step)
+		return p
+	}
+	p.value = value
+	return p
+}
+
+// Result returns the value produced by the last step, and nil, or the zero value and the error of the failing step.
+func (p *Pipe[T]) Result() (T, error) {
+	return p.value, p.err
+}
+
+// PipeTo works like Pipe.Then, but the fn converts the value to another type:
+//
+//	n, err := errbox.PipeTo(errbox.StartPipe(readInput), strconv.Atoi).Result()
+func PipeTo[T, U any](p *Pipe[T], fn func(value T) (U, error)) *Pipe[U] {
+	next := &Pipe[U]{err: p.err, step: p.step}
+	if p.err != nil {
+		return next
+	}
+	next.step++
+	value, err := fn(p.value)
+	if err != nil {
+		next.err = annotateErr(3, err, "pipe step %d", next.step)
+		return next
+	}
+	next.value = value
+	return next
+}
@@ -0,0 +1,37 @@
+package errbox
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPipe(t *testing.T) {
+	double := func(n int) (int, error) { return n * 2, nil }
+	n, err := PipeTo(StartPipe(func() (string, error) { return "21", nil }), strconv.Atoi).Then(double).Result()
+	if err != nil || n != 42 {
+		t.Errorf("expected 42, got %v, %v", n, err)
+	}
+
+	var called bool
+	n, err = PipeValue(1).
+		Then(double).
+		Then(func(int) (int, error) { return 7, errors.New("overflow") }).
+		Then(func(n int) (int, error) { called = true; return n, nil }).
+		Result()
+	if called {
+		t.Errorf("expected the step after the failure to be skipped")
+	}
+	if n != 0 || Cause(err).Error() != "overflow" {
+		t.Errorf("expected the overflow, got %v, %v", n, err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "pipe step 2") || !strings.Contains(msg, "pipe_test.go") {
+		t.Errorf("expected the failing step to be annotated, got:\n%s", msg)
+	}
+
+	_, err = PipeTo(StartPipe(func() (string, error) { return "", errors.New("no input") }), strconv.Atoi).Result()
+	if Cause(err).Error() != "no input" || !strings.Contains(err.Error(), "pipe step 1") {
+		t.Errorf("expected the first step to fail, got:\n%v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package errbox
+
+import "strings"
+
+// RemoteErr is an error which happened in another process (typically, another service), and which was
+// reconstructed by FromJSON (or by other decoders built on top of it) from an error encoded while
+// the ServiceName option was set. The error is printed out as a separate section, so that logs show both
+// hops clearly delineated:
+//
+//	error in service billing:
+//	    card declined
+//	     +--> charging card
+//	 +--> calling billing
+//
+// Errors.Is, errors.As, CodeOf, FieldOf and friends see through the RemoteErr, to the remote error.
+type RemoteErr struct {
+	Service string // name of the service where the error happened, empty if it is not known
+	Code    string // code of the remote error, see CodeOf
+
+	err *StackErr // the remote error
+}
+
+// Error returns the name of the service, and the remote error indented below it.
+func (r *RemoteErr) Error() string {
+	var sb strings.Builder
+	if r.Service != "" {
+		sb.WriteString("error in service " + r.Service + ":\n")
+	} else {
+		sb.WriteString("remote error:\n")
+	}
+	writeIndented(&sb, r.Trace())
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Trace returns the remote error, printed out as it would be printed out by the remote service.
+func (r *RemoteErr) Trace() string {
+	return r.err.Error()
+}
+
+// Unwrap returns the remote error.
+func (r *RemoteErr) Unwrap() error {
+	return r.err
+}
+
+// toRecord returns the JSON representation of the remote error.
+func (r *RemoteErr) toRecord() *Record {
+	je := r.err.toRecord()
+	je.Service = r.Service
+	return je
+}
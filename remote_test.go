@@ -0,0 +1,54 @@
+package errbox
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// hop encodes the err as the service, and decodes it back, as if the err was sent to another service.
+func hop(t *testing.T, service string, err error) error {
+	t.Helper()
+	opts := CurrentOptions()
+	defer Configure(opts)
+	withService := opts
+	withService.ServiceName = service
+	Configure(withService)
+
+	data, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	got, jerr := FromJSON(data)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	return got
+}
+
+func TestRemoteErr(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+
+	declined := errors.New("card declined")
+	err := hop(t, "billing", WithCode(Annotate(declined, "charging card"), "CARD_DECLINED"))
+	err = Annotate(err, "calling billing")
+	want := "error in service billing:\n    card declined\n     +--> charging card\n     code: CARD_DECLINED\n +--> calling billing\n"
+	if s := err.Error(); s != want {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+
+	var remote *RemoteErr
+	if !errors.As(err, &remote) || remote.Service != "billing" || remote.Code != "CARD_DECLINED" {
+		t.Errorf("expected remote error, got %#v", remote)
+	}
+	if CodeOf(err) != "CARD_DECLINED" {
+		t.Errorf("expected code of the remote error")
+	}
+
+	err = hop(t, "shop", err)
+	want = "error in service shop:\n    error in service billing:\n        card declined\n         +--> charging card\n         code: CARD_DECLINED\n     +--> calling billing"
+	if s := err.Error(); s != want {
+		t.Errorf("unexpected rendering after two hops:\n%s", s)
+	}
+}
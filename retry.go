@@ -0,0 +1,109 @@
+package errbox
+
+import "errors"
+
+// MarkRetryable returns the err (as *StackErr) marked as retryable, or nil, if the err is nil.
+// Retry loops can then use IsRetryable instead of maintaining lists of sentinel errors:
+//
+//	if resp.StatusCode == http.StatusServiceUnavailable {
+//		return errbox.MarkRetryable(errbox.Unavailable("payment gateway"))
+//	}
+//
+// Call of MarkRetryable on error which is a *Box marks all errors in the box.
+func MarkRetryable(err error) error {
+	return markRetryable(err, on)
+}
+
+// Permanent returns the err (as *StackErr) marked as NOT retryable, or nil, if the err is nil.
+// It overrides MarkRetryable of errors wrapped by the err. See MarkRetryable.
+func Permanent(err error) error {
+	return markRetryable(err, off)
+}
+
+// markRetryable implements MarkRetryable and Permanent.
+func markRetryable(err error, retryable toggle) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			markRetryable(e, retryable)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.retryable = retryable
+	return be
+}
+
+// IsRetryable returns true if the err was marked by MarkRetryable, and it was not marked by Permanent since.
+// Marks of the err, and of all errors it wraps are considered, the outermost mark wins.
+// Errors which were not marked are retryable if they are of KindUnavailable (see KindOf and RegisterClassifier).
+//
+// When the err is a *Box, or it wraps one (like a group, see Box.Group), it is retryable only if all errors
+// in the box are retryable (retrying would not help with the rest of them), unless the error which wraps the box
+// is marked. Returns false if the err is nil, or if it is an empty box.
+func IsRetryable(err error) bool {
+	if isNil(err) {
+		return false
+	}
+	if retryable, ok := lookupOuter(err, (*StackErr).ownRetryable); ok {
+		return retryable
+	}
+	if b := boxIn(err); b != nil {
+		errs := Errors(b)
+		for _, e := range errs {
+			if !IsRetryable(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	return KindOf(err) == KindUnavailable
+}
+
+// ownRetryable returns true if the error is retryable, the second value is false if the error was not marked.
+func (b *StackErr) ownRetryable() (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retryable.resolve(false), b.retryable != inherit
+}
+
+// Timeout forwards Timeout of the cause (or of any error the cause wraps), like net.Error, so that wrapping
+// network errors does not hide their timeout nature. Returns false if no such error is found.
+//
+// Together with Temporary, it makes every StackErr a net.Error: errors.As(err, &netErr) succeeds for any StackErr,
+// even if it does not wrap a network error, and netErr is the StackErr, not the network error. To detect network
+// errors, use errors.As with a concrete type (like *net.OpError), or IsTimeout.
+func (b *StackErr) Timeout() bool {
+	var t interface{ Timeout() bool }
+	return errors.As(b.cause, &t) && t.Timeout()
+}
+
+// Temporary forwards Temporary of the cause (or of any error the cause wraps). It makes StackErr a net.Error,
+// see Timeout.
+func (b *StackErr) Temporary() bool {
+	var t interface{ Temporary() bool }
+	return errors.As(b.cause, &t) && t.Temporary()
+}
+
+// IsTimeout returns true if the err, or any error it wraps, reports a timeout by its Timeout method
+// (like net.Error, or context.DeadlineExceeded). When the err is a *Box, it returns true if any of the errors
+// in the box is a timeout.
+func IsTimeout(err error) bool {
+	if isNil(err) {
+		return false
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			if IsTimeout(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
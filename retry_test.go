@@ -0,0 +1,76 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	retryable := MarkRetryable(errors.New("busy"))
+	if !IsRetryable(fmt.Errorf("calling: %w", Annotate(retryable, "charging"))) {
+		t.Errorf("expected retryable error to survive wrapping")
+	}
+	if IsRetryable(Permanent(fmt.Errorf("charging: %w", MarkRetryable(errors.New("busy"))))) {
+		t.Errorf("expected Permanent to override inner mark")
+	}
+	if IsRetryable(errors.New("plain")) || IsRetryable(nil) {
+		t.Errorf("expected unmarked errors not to be retryable")
+	}
+
+	box := Append(Append(nil, retryable), MarkRetryable(errors.New("timeout")))
+	if !IsRetryable(box) {
+		t.Errorf("expected box of retryable errors to be retryable")
+	}
+	if IsRetryable(Append(box, errors.New("bad request"))) {
+		t.Errorf("expected box with permanent error not to be retryable")
+	}
+
+	mixed := NewBox()
+	mixed.Group("calls").PushIf(MarkRetryable(errors.New("busy")), "")
+	mixed.Group("calls").PushIf(Permanent(errors.New("rejected")), "")
+	if IsRetryable(mixed) || IsRetryable(fmt.Errorf("batch: %w", mixed.Group("calls"))) {
+		t.Errorf("expected group with permanent error not to be retryable")
+	}
+	if !IsRetryable(MarkRetryable(fmt.Errorf("batch: %w", mixed))) {
+		t.Errorf("expected the mark of the wrapping error to win")
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	err := Annotate(&net.DNSError{Err: "i/o timeout", IsTimeout: true, IsTemporary: true}, "resolving host")
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected the error to be a timeout")
+	}
+	if !err.(*StackErr).Temporary() {
+		t.Errorf("expected the error to be temporary")
+	}
+	box := Append(Append(nil, errors.New("bad row")), Annotate(context.DeadlineExceeded, "querying"))
+	if !IsTimeout(box) {
+		t.Errorf("expected the box to contain a timeout")
+	}
+	if IsTimeout(errors.New("plain")) || IsTimeout(nil) {
+		t.Errorf("expected no timeout")
+	}
+}
+
+func TestStackErrIsNetError(t *testing.T) {
+	// every StackErr is a net.Error, which forwards the cause
+	var netErr net.Error
+	if err := Annotate(io.EOF, "reading"); !errors.As(err, &netErr) || netErr != err || netErr.Timeout() || netErr.Temporary() {
+		t.Errorf("expected the StackErr itself to be found, without a timeout")
+	}
+	// concrete types find the network error
+	var opErr *net.OpError
+	cause := &net.OpError{Op: "dial", Err: context.DeadlineExceeded}
+	if !errors.As(Annotate(cause, "connecting"), &opErr) || opErr != cause {
+		t.Errorf("expected the network error to be found")
+	}
+	if IsTimeout(Annotate(io.EOF, "reading")) {
+		t.Errorf("expected no timeout")
+	}
+}
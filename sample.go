@@ -0,0 +1,38 @@
+package errbox
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// unsampledStacks counts errors, for which places in code were not recorded, see SampleStacks.
+var unsampledStacks uint64
+
+// SampleStacks will SET the StackDropRate option to 1 - rate: places in code (file, line and function) are recorded
+// only for the fraction of errors, like 0.01 for 1 % of them, to bound the cost of tracing on hot paths
+// of high-throughput services. Annotations of the other errors keep their messages only; such errors are
+// counted, see UnsampledStacks.
+//
+// The decision is made once per error, when it is annotated for the first time. Rate 1 (or more) records places
+// for all errors (the default), rate 0 (or less) for none of them.
+//
+// It is safe to call this function at any time, see Configure.
+func SampleStacks(rate float64) {
+	update(func(opts *Options) { opts.StackDropRate = 1 - rate })
+}
+
+// UnsampledStacks returns the number of errors, for which places in code were not recorded, because they were not
+// sampled (see SampleStacks), since the program started.
+func UnsampledStacks() uint64 {
+	return atomic.LoadUint64(&unsampledStacks)
+}
+
+// sampleStack returns true if places in code should be recorded for a new error. If not, the error is counted.
+func sampleStack() bool {
+	drop := currentOptions().StackDropRate
+	if drop <= 0 || (drop < 1 && rand.Float64() >= drop) {
+		return true
+	}
+	atomic.AddUint64(&unsampledStacks, 1)
+	return false
+}
@@ -0,0 +1,54 @@
+package errbox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSampleStacks(t *testing.T) {
+	defer SampleStacks(1)
+
+	SampleStacks(0)
+	before := UnsampledStacks()
+	err := WithStack(errors.New("hot path"))
+	_ = Annotate(err, "first")
+	_ = Annotate(err, "second")
+	if UnsampledStacks() != before+1 {
+		t.Errorf("expected the error to be counted once, got %d", UnsampledStacks()-before)
+	}
+	for _, anno := range err.Annotations() {
+		if anno.Line != 0 || anno.File != "" {
+			t.Errorf("expected no place in code, got %+v", anno)
+		}
+	}
+	if len(err.Annotations()) != 2 || len(err.Callers()) != 0 {
+		t.Errorf("expected messages without places, got %+v", err.Annotations())
+	}
+
+	SampleStacks(1)
+	if rate := CurrentOptions().StackDropRate; rate != 0 {
+		t.Errorf("expected rate 1 to be the default, got %v", rate)
+	}
+	if anno := WithStack(Annotate(errors.New("boom"), "")).Annotations(); anno[0].Line == 0 {
+		t.Errorf("expected the place in code to be recorded")
+	}
+}
+
+func TestSampleStacksCallSites(t *testing.T) {
+	defer SampleStacks(1)
+	SampleStacks(0)
+
+	retryA := func(err error) error { return Annotate(err, "retry") }
+	retryB := func(err error) error { return Annotate(err, "retry") }
+	err := retryB(retryA(errors.New("hot path")))
+	if anno := WithStack(err).Annotations(); len(anno) != 2 || anno[0].Repeated != 0 {
+		t.Errorf("expected annotations from two places not to be collapsed, got %+v", anno)
+	}
+
+	box := NewBox().WithDedup(true)
+	box.PushIf(errors.New("timeout"), "call")
+	box.PushIf(errors.New("timeout"), "call")
+	if n := len(Errors(box)); n != 2 {
+		t.Errorf("expected unsampled errors not to be merged, got %d", n)
+	}
+}
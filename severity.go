@@ -0,0 +1,109 @@
+package errbox
+
+// Severity tells how serious the error is, see WithSeverity. Severities are ordered, so they can be compared:
+// SeverityWarning < SeverityError < SeverityFatal. The zero value means that no severity is known.
+type Severity int
+
+const (
+	// SeverityWarning is a finding which does not make the operation fail.
+	SeverityWarning Severity = iota + 1
+	// SeverityError is the severity of errors which have no other severity set.
+	SeverityError
+	// SeverityFatal is an error after which the program should not continue.
+	SeverityFatal
+)
+
+// String returns the name of the severity, like "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	}
+	return ""
+}
+
+// severityByName returns the severity with the name (see Severity.String), or zero.
+func severityByName(name string) Severity {
+	for s := SeverityWarning; s <= SeverityFatal; s++ {
+		if s.String() == name {
+			return s
+		}
+	}
+	return 0
+}
+
+// WithSeverity returns the err (as *StackErr) with the severity set, or nil, if the err is nil.
+//
+//	box.Append(errbox.WithSeverity(fmt.Errorf("column %q is deprecated", name), errbox.SeverityWarning))
+//
+// Call of WithSeverity on error which is a *Box sets the severity on all errors in the box.
+func WithSeverity(err error, severity Severity) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range Errors(b) {
+			WithSeverity(e, severity)
+		}
+		return b
+	}
+	be := WithStack(err)
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	be.severity = severity
+	return be
+}
+
+// SeverityOf returns the severity set by WithSeverity on the err, or on any error it wraps (the outermost one wins).
+// Errors without the severity are SeverityError. When the err is a *Box, its MaxSeverity is returned, and so it is
+// when the err wraps a box (like a group, see Box.Group), unless the severity is set on the wrapping error.
+// Zero is returned for nil error.
+func SeverityOf(err error) Severity {
+	if isNil(err) {
+		return 0
+	}
+	if sb, ok := err.(*ShardedBox); ok {
+		err = sb.Box() // a snapshot of all shards
+	}
+	if b, ok := err.(*Box); ok {
+		return b.MaxSeverity()
+	}
+	if s, ok := lookupOuter(err, (*StackErr).ownSeverity); ok {
+		return s
+	}
+	if b := boxIn(err); b != nil && !b.IsEmpty() {
+		return b.MaxSeverity()
+	}
+	return SeverityError
+}
+
+// MaxSeverity returns the highest severity of errors in the box (see SeverityOf), so that a box of mixed findings
+// can decide the overall result. Groups and wrapped boxes are searched as well. Zero is returned for empty box.
+func (b *Box) MaxSeverity() Severity {
+	var highest Severity
+	for _, err := range Errors(b) {
+		if s := SeverityOf(err); s > highest {
+			highest = s
+		}
+	}
+	return highest
+}
+
+// ownSeverity returns the severity set directly on the error, and true if it was set.
+func (b *StackErr) ownSeverity() (Severity, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.severity, b.severity != 0
+}
+
+// formatSeverity returns the severity set directly on the error, like " [warning]", or empty string if it is not set.
+func formatSeverity(err *StackErr) string {
+	if s, ok := err.ownSeverity(); ok {
+		return " [" + s.String() + "]"
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSeverity(t *testing.T) {
+	warn := WithSeverity(errors.New("deprecated column"), SeverityWarning)
+	if s := SeverityOf(Annotate(warn, "loading")); s != SeverityWarning {
+		t.Errorf("expected warning, got %v", s)
+	}
+	if s := SeverityOf(errors.New("plain")); s != SeverityError {
+		t.Errorf("expected error, got %v", s)
+	}
+
+	err := Append(nil, warn)
+	if s := SeverityOf(err); s != SeverityWarning {
+		t.Errorf("expected warning, got %v", s)
+	}
+	err = Append(err, WithSeverity(errors.New("disk full"), SeverityFatal))
+	err = Append(err, errors.New("bad row"))
+	if s := err.(*Box).MaxSeverity(); s != SeverityFatal {
+		t.Errorf("expected fatal, got %v", s)
+	}
+	if !strings.Contains(err.Error(), "# 1 [warning]\n") {
+		t.Errorf("expected severity in the header:\n%s", err.Error())
+	}
+	if s := new(Box).MaxSeverity(); s != 0 {
+		t.Errorf("expected no severity for empty box, got %v", s)
+	}
+
+	mixed := NewBox()
+	mixed.Group("rows").PushIf(warn, "")
+	mixed.Group("rows").PushIf(errors.New("bad row"), "")
+	if s := mixed.MaxSeverity(); s != SeverityError {
+		t.Errorf("expected error from the group, got %v", s)
+	}
+	if s := SeverityOf(fmt.Errorf("import: %w", mixed)); s != SeverityError {
+		t.Errorf("expected error from the wrapped box, got %v", s)
+	}
+}
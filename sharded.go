@@ -0,0 +1,214 @@
+package errbox
+
+import (
+	"errors"
+	"hash/fnv"
+	"runtime"
+	"sync/atomic"
+)
+
+// ShardedBox is a Box for high-concurrency writers. Errors are spread over several boxes (shards), each with its own
+// mutex, so that hundreds of goroutines pushing errors do not contend on a single lock. Shards are merged on read.
+//
+// ShardedBox has the same API for pushing errors as the Box, and it works with errors.Is, errors.As and helpers
+// of this package (like HTTPStatus, or ExitCode) as the Box does. Use Box to get all errors as a single *Box,
+// for anything else. Errors pushed by different goroutines are not ordered.
+type ShardedBox struct {
+	shards []*Box
+	next   uint32       // round-robin counter used to select the shard
+	last   atomic.Value // *StackErr pushed last, so that it is not pushed again right away, see Box.PushIf
+	dedup  uint32       // 1 if errors are deduplicated, see WithDedup
+}
+
+// NewShardedBox returns a new ShardedBox pointer with n shards. If n is not positive, runtime.GOMAXPROCS(0) is used.
+func NewShardedBox(n int) *ShardedBox {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	sb := &ShardedBox{shards: make([]*Box, n)}
+	for i := range sb.shards {
+		sb.shards[i] = NewBox()
+	}
+	return sb
+}
+
+// shard returns the shard which should receive the next error.
+func (sb *ShardedBox) shard() *Box {
+	i := atomic.AddUint32(&sb.next, 1)
+	return sb.shards[int(i)%len(sb.shards)]
+}
+
+// PushIf adds the error to the box, and returns true if the first parameter was not nil. See Box.PushIf.
+func (sb *ShardedBox) PushIf(err error, message string, args ...interface{}) bool {
+	return sb.pushIf(err, message, args...) != nil
+}
+
+// PushIfErr adds the error to the box, and returns the annotated error if the first parameter was not nil.
+// See Box.PushIfErr.
+func (sb *ShardedBox) PushIfErr(err error, message string, args ...interface{}) error {
+	if this := sb.pushIf(err, message, args...); this != nil {
+		return this
+	}
+	if isNil(err) {
+		return nil
+	}
+	return err // refused to create a cycle
+}
+
+// pushIf implements PushIf and PushIfErr, user code is three stack frames up.
+// It returns nil if the err is nil, or if it contains the box (see isIn).
+func (sb *ShardedBox) pushIf(err error, message string, args ...interface{}) *StackErr {
+	if isNil(err) || sb.isIn(err) {
+		return nil
+	}
+	// annotate this error (give it stack trace and additional message
+	this, isNew := withStack(err)
+	this.annotate(3, message, args...)
+	if isNew {
+		created(this)
+	}
+	sb.push(this)
+	return this
+}
+
+// pushErr pushes the err into the box, see Box.pushErr. Nil err is ignored, and so is an err which contains the box.
+func (sb *ShardedBox) pushErr(err error) {
+	if isNil(err) || sb.isIn(err) {
+		return
+	}
+	for _, e := range Errors(err) {
+		sb.push(e.(*StackErr))
+	}
+}
+
+// push pushes the error into one of the shards, unless it is the same as the last error pushed into the box.
+func (sb *ShardedBox) push(this *StackErr) {
+	if last, _ := sb.last.Swap(this).(*StackErr); last == this {
+		return
+	}
+	shard := sb.shard()
+	if atomic.LoadUint32(&sb.dedup) == 1 {
+		shard = sb.shardOf(this)
+	}
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.push(this)
+}
+
+// shardOf returns the shard which receives errors with the same fingerprint as the err, see WithDedup.
+func (sb *ShardedBox) shardOf(err *StackErr) *Box {
+	h := fnv.New32a()
+	h.Write([]byte(Fingerprint(err)))
+	return sb.shards[int(h.Sum32()%uint32(len(sb.shards)))]
+}
+
+// WithDedup turns on (or off) deduplication of errors in the box, and returns the box back, see Box.WithDedup.
+// Errors with the same fingerprint are sent to the same shard, which then deduplicates them.
+func (sb *ShardedBox) WithDedup(on bool) *ShardedBox {
+	var dedup uint32
+	if on {
+		dedup = 1
+	}
+	atomic.StoreUint32(&sb.dedup, dedup)
+	for _, shard := range sb.shards {
+		shard.WithDedup(on)
+	}
+	return sb
+}
+
+// OnPush registers the fn, which is called with every error pushed into any shard of the box, see Box.OnPush.
+// The fn may be called from more goroutines at the same time.
+func (sb *ShardedBox) OnPush(fn func(err *StackErr)) {
+	for _, shard := range sb.shards {
+		shard.OnPush(fn)
+	}
+}
+
+// Go runs the fn in a new goroutine, and pushes the error returned by the fn (if any) into the box, see Box.Go.
+func (sb *ShardedBox) Go(fn func() error) {
+	sb.shard().goWith(captureOrigin(3), fn, sb.pushErr)
+}
+
+// Wait waits until all goroutines started by Go are finished. It returns the box, if it contains any error,
+// or nil, if it is empty.
+func (sb *ShardedBox) Wait() error {
+	for _, shard := range sb.shards {
+		shard.wg.Wait()
+	}
+	if sb.IsEmpty() {
+		return nil
+	}
+	return sb
+}
+
+// isIn returns true if the err is the box, or if it contains the box or any of its shards (see Box.isIn).
+// Such errors are not added to the box, because the box would contain itself.
+func (sb *ShardedBox) isIn(err error) bool {
+	found := false
+	Walk(err, func(e error) bool {
+		other, ok := e.(*ShardedBox)
+		found = ok && other == sb
+		return !found
+	})
+	for _, shard := range sb.shards {
+		found = found || shard.isIn(err)
+	}
+	return found
+}
+
+// Len returns the number of errors in all shards.
+func (sb *ShardedBox) Len() int {
+	n := 0
+	for _, shard := range sb.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// IsEmpty returns true if the box contains no errors.
+func (sb *ShardedBox) IsEmpty() bool {
+	return sb.Len() == 0
+}
+
+// Box merges errors of all shards into a new *Box.
+func (sb *ShardedBox) Box() *Box {
+	b := NewBox()
+	for _, shard := range sb.shards {
+		b.Merge(shard)
+	}
+	return b
+}
+
+// Error implements the error interface, see Box.Error.
+func (sb *ShardedBox) Error() string {
+	return sb.Box().Error()
+}
+
+// Unwrap returns shards of the box, so that Walk (and errors.Is, and errors.As) can look into them.
+func (sb *ShardedBox) Unwrap() []error {
+	errs := make([]error, len(sb.shards))
+	for i, shard := range sb.shards {
+		errs[i] = shard
+	}
+	return errs
+}
+
+// Is makes the box match errors stored in it, see Box.Is.
+func (sb *ShardedBox) Is(target error) bool {
+	for _, shard := range sb.shards {
+		if errors.Is(shard, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As makes the box match errors stored in it, see Box.As. Shards are searched in order.
+func (sb *ShardedBox) As(target interface{}) bool {
+	for _, shard := range sb.shards {
+		if errors.As(shard, target) {
+			return true
+		}
+	}
+	return false
+}
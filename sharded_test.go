@@ -0,0 +1,99 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedBox(t *testing.T) {
+	ShowStack(true)
+	sb := NewShardedBox(4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sb.PushIf(fmt.Errorf("error %d", i), "worker %d", i)
+		}(i)
+	}
+	wg.Wait()
+	if sb.PushIfErr(nil, "") != nil {
+		t.Errorf("expected nil error to be ignored")
+	}
+
+	if sb.Len() != 50 || len(Errors(sb)) != 50 {
+		t.Errorf("expected 50 errors, got %d", sb.Len())
+	}
+	if s := sb.Error(); !strings.Contains(s, "(TestShardedBox.func1)") {
+		t.Errorf("expected call site of the worker in the output: %s", s[:200])
+	}
+}
+
+func TestShardedBoxCycle(t *testing.T) {
+	sb := NewShardedBox(2)
+	sb.PushIf(fmt.Errorf("first"), "")
+	if sb.PushIf(sb, "self") || sb.PushIf(fmt.Errorf("wrapped: %w", sb), "") || sb.PushIf(sb.shards[0], "") {
+		t.Errorf("expected the box not to be pushed into itself")
+	}
+	if err := sb.PushIfErr(sb, ""); err != sb {
+		t.Errorf("expected the box to be returned as it is, got %v", err)
+	}
+	if sb.Len() != 1 || !strings.Contains(sb.Error(), "first") {
+		t.Errorf("expected one error, got %d", sb.Len())
+	}
+}
+
+func TestShardedBoxAPI(t *testing.T) {
+	sb := NewShardedBox(4)
+	for i := 0; i < 3; i++ {
+		sb.PushIf(NotFound("user %d", i), "")
+	}
+	sb.PushIf(io.EOF, "reading")
+	if !IsInside(sb, io.EOF) || !errors.Is(fmt.Errorf("batch: %w", sb), io.EOF) {
+		t.Errorf("expected io.EOF to be found in the box")
+	}
+	var se *StackErr
+	if !errors.As(sb, &se) {
+		t.Errorf("expected errors.As to find an error in the box")
+	}
+
+	notFound := NewShardedBox(4)
+	for i := 0; i < 3; i++ {
+		notFound.PushIf(NotFound("user %d", i), "")
+	}
+	if s := HTTPStatus(notFound); s != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", s)
+	}
+	if s := HTTPStatus(NewShardedBox(2)); s != http.StatusOK {
+		t.Errorf("expected 200 for empty box, got %d", s)
+	}
+	if ExitCode(notFound) != 1 || SeverityOf(notFound) != SeverityError || KindOf(notFound) != KindNotFound {
+		t.Errorf("expected helpers to look into shards")
+	}
+
+	// the same error pushed twice in a row is kept once, as in a Box
+	same := NewShardedBox(4)
+	err := errors.New("same")
+	same.PushIf(err, "")
+	same.PushIf(WithStack(Errors(same)[0]), "")
+	if same.Len() != 1 {
+		t.Errorf("expected the same error to be kept once, got %d", same.Len())
+	}
+
+	var pushed int32
+	dedup := NewShardedBox(4).WithDedup(true)
+	dedup.OnPush(func(err *StackErr) { atomic.AddInt32(&pushed, 1) })
+	for i := 0; i < 8; i++ {
+		dedup.Go(func() error { return Annotate(errors.New("timeout"), "calling") })
+	}
+	if err := dedup.Wait(); err == nil || dedup.Len() != 1 || atomic.LoadInt32(&pushed) != 1 {
+		t.Errorf("expected one deduplicated error, got %d (%d pushed)", dedup.Len(), pushed)
+	}
+}
@@ -0,0 +1,105 @@
+package errbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Shutdown collects close functions of subsystems, and runs them when the program terminates, collecting
+// all failures into a Box. The zero value is ready to use:
+//
+//	var shutdown errbox.Shutdown
+//	shutdown.Register("database", func(ctx context.Context) error { return db.Close() })
+//	shutdown.Register("http server", server.Shutdown)
+//	...
+//	if err := shutdown.Run(ctx); err != nil {
+//		log.Println(err)
+//	}
+type Shutdown struct {
+	// Parallel runs all close functions at once. Otherwise, they run one after another, in reverse order
+	// of registration (like deferred calls), so that subsystems are closed before subsystems they depend on.
+	Parallel bool
+	// Timeout limits how long every close function may run. Zero means no limit (other than the context of Run).
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	steps []shutdownStep
+}
+
+// shutdownStep is a close function registered in Shutdown.
+type shutdownStep struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Register registers the close function fn of the subsystem with the name. The fn should give up
+// when the ctx is done.
+func (s *Shutdown) Register(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, shutdownStep{name: name, fn: fn})
+}
+
+// Run runs all registered close functions, and returns a Box with their failures, each of them annotated
+// with the name of the subsystem, or nil, if all of them succeeded. Panics in close functions are recovered
+// and reported as failures (see FromPanic).
+//
+// If a close function does not return in time (see Timeout), Run stops waiting for it, and reports
+// the error of its context (context.DeadlineExceeded) instead. Close functions which did not start yet,
+// because the ctx was done, fail the same way.
+func (s *Shutdown) Run(ctx context.Context) error {
+	s.mu.Lock()
+	steps := append([]shutdownStep(nil), s.steps...)
+	s.mu.Unlock()
+
+	box := NewBox()
+	if s.Parallel {
+		var wg sync.WaitGroup
+		for _, step := range steps {
+			wg.Add(1)
+			go func(step shutdownStep) {
+				defer wg.Done()
+				box.pushErr(s.run(ctx, step))
+			}(step)
+		}
+		wg.Wait()
+	} else {
+		for i := len(steps) - 1; i >= 0; i-- {
+			box.pushErr(s.run(ctx, steps[i]))
+		}
+	}
+	if box.IsEmpty() {
+		return nil
+	}
+	return box
+}
+
+// run runs the close function of the step, and returns its error annotated with the name of the subsystem.
+func (s *Shutdown) run(ctx context.Context, step shutdownStep) error {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return Annotate(err, "shutdown of %s", step.name)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { done <- err }()
+		defer Recover(&err)
+		err = step.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			return nil
+		}
+		return Annotate(err, "shutdown of %s", step.name)
+	case <-ctx.Done():
+		return Annotate(ctx.Err(), "shutdown of %s", step.name)
+	}
+}
@@ -0,0 +1,67 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	var (
+		shutdown Shutdown
+		mu       sync.Mutex
+		order    []string
+	)
+	closer := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return err
+		}
+	}
+	shutdown.Register("database", closer("database", errors.New("connection reset")))
+	shutdown.Register("cache", closer("cache", nil))
+	shutdown.Register("server", func(ctx context.Context) error { panic("bang") })
+
+	err := shutdown.Run(context.Background())
+	if strings.Join(order, ",") != "cache,database" {
+		t.Errorf("expected reverse order, got %v", order)
+	}
+	errs := Errors(err)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	if !errors.Is(errs[0], ErrPanic) || !strings.Contains(errs[0].Error(), "shutdown of server") {
+		t.Errorf("expected panic of server, got:\n%s", errs[0])
+	}
+	if Cause(errs[1]).Error() != "connection reset" || !strings.Contains(errs[1].Error(), "shutdown of database") {
+		t.Errorf("expected failure of database, got:\n%s", errs[1])
+	}
+}
+
+func TestShutdownParallelTimeout(t *testing.T) {
+	shutdown := Shutdown{Parallel: true, Timeout: 10 * time.Millisecond}
+	shutdown.Register("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	shutdown.Register("fine", func(ctx context.Context) error { return nil })
+
+	start := time.Now()
+	err := shutdown.Run(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected Run to give up on the stuck step")
+	}
+	errs := Errors(err)
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) || !strings.Contains(errs[0].Error(), "shutdown of stuck") {
+		t.Errorf("expected timeout of the stuck step, got:\n%v", err)
+	}
+
+	if err := new(Shutdown).Run(context.Background()); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package errbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer. The error is logged as a group with the cause, annotation messages,
+// stack frames and fields, so that slog.Error("failed", "err", err) produces structured output.
+//
+// Stack frames are omitted if the stack trace would not be printed out (see ShowStack and WithStackOutput).
+func (b *StackErr) LogValue() slog.Value {
+	return slog.GroupValue(b.logAttrs(b.stack.resolve(!currentOptions().HideStack))...)
+}
+
+// logAttrs returns attributes of the error, showStack controls if stack frames are included.
+func (b *StackErr) logAttrs(showStack bool) []slog.Attr {
+	if b.group != "" {
+		return []slog.Attr{
+			slog.String("group", b.group),
+			slog.Attr{Key: "errors", Value: b.cause.(*Box).logValue(toggleOf(showStack))},
+		}
+	}
+	attrs := []slog.Attr{slog.String("cause", b.cause.Error())}
+
+	var messages, frames []string
+	for _, anno := range b.annotations() {
+		if message := anno.text(); message != "" {
+			messages = append(messages, message)
+		}
+		if anno.line > 0 {
+			frames = append(frames, fmt.Sprintf("%s:%d (%s)", anno.file, anno.line, anno.function))
+		}
+	}
+	if len(messages) > 0 {
+		attrs = append(attrs, slog.Any("annotations", messages))
+	}
+	if showStack && len(frames) > 0 {
+		attrs = append(attrs, slog.Any("frames", frames))
+	}
+
+	if code, ok := b.ownCode(); ok {
+		attrs = append(attrs, slog.String("code", code))
+	}
+	if kind, ok := b.ownKind(); ok {
+		attrs = append(attrs, slog.String("kind", kind.String()))
+	}
+	if severity, ok := b.ownSeverity(); ok {
+		attrs = append(attrs, slog.String("severity", severity.String()))
+	}
+	if hints := b.ownHints(); len(hints) > 0 {
+		attrs = append(attrs, slog.Any("hints", hints))
+	}
+	if suppressed := b.Suppressed(); len(suppressed) > 0 {
+		messages := make([]string, len(suppressed))
+		for i, err := range suppressed {
+			messages[i] = Cause(err).Error()
+		}
+		attrs = append(attrs, slog.Any("suppressed", messages))
+	}
+	if tags := b.Tags(); len(tags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		group := make([]slog.Attr, 0, len(keys))
+		for _, k := range keys {
+			group = append(group, slog.String(k, tags[k]))
+		}
+		attrs = append(attrs, slog.Attr{Key: "tags", Value: slog.GroupValue(group...)})
+	}
+	if fields := b.CopyFields(); len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		group := make([]slog.Attr, 0, len(keys))
+		for _, k := range keys {
+			group = append(group, slog.Any(k, fields[k]))
+		}
+		attrs = append(attrs, slog.Attr{Key: "fields", Value: slog.GroupValue(group...)})
+	}
+	return attrs
+}
+
+// LogValue implements slog.LogValuer. The box is logged as a group with the count of errors,
+// and every error (see StackErr.LogValue) as a nested group keyed by its position in the box, starting with 1.
+func (b *Box) LogValue() slog.Value {
+	return b.logValue(inherit)
+}
+
+// logValue implements LogValue, stack is the ShowStack override inherited from the parent box, if any.
+func (b *Box) logValue(stack toggle) slog.Value {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	showStack := b.stack.resolve(stack.resolve(!currentOptions().HideStack))
+	entries := b.entries()
+	attrs := []slog.Attr{slog.Int("count", len(entries))}
+	for i, err := range entries {
+		group := err.logAttrs(err.stack.resolve(showStack))
+		attrs = append(attrs, slog.Attr{Key: strconv.Itoa(i + 1), Value: slog.GroupValue(group...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// SlogAttrs returns details of the err as a list of attributes (see StackErr.LogValue and Box.LogValue),
+// for callers who want to splat them into an existing log record rather than nest them under one key:
+//
+//	logger.LogAttrs(ctx, slog.LevelError, "failed", errbox.SlogAttrs(err)...)
+//
+// Errors of other types are converted by WithStack. Returns nil if the err is nil.
+func SlogAttrs(err error) []slog.Attr {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		return b.LogValue().Group()
+	}
+	return Inspect(err).LogValue().Group()
+}
+
+// SlogHandler returns a slog.Handler, which passes records to the next handler, after it expanded errbox
+// errors found in attributes to structured groups (see SlogAttrs). Errors which are *StackErr or *Box are logged
+// as groups even without the handler (see StackErr.LogValue); the handler also expands errors which wrap them,
+// like fmt.Errorf("handling request: %w", err), so that teams get the structure without changing call sites:
+//
+//	slog.SetDefault(slog.New(errbox.SlogHandler(slog.NewJSONHandler(os.Stderr, nil))))
+//
+// The expanded group of an error which wraps an errbox error has one more attribute, "message", with the message
+// of the whole error.
+func SlogHandler(next slog.Handler) slog.Handler {
+	return &slogHandler{next: next}
+}
+
+// slogHandler implements SlogHandler.
+type slogHandler struct {
+	next slog.Handler
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	expanded := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		expanded.AddAttrs(expandAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, expanded)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		expanded[i] = expandAttr(attr)
+	}
+	return &slogHandler{next: h.next.WithAttrs(expanded)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{next: h.next.WithGroup(name)}
+}
+
+// expandAttr expands errbox errors in the attribute, see SlogHandler.
+func expandAttr(attr slog.Attr) slog.Attr {
+	v := attr.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		expanded := make([]slog.Attr, len(group))
+		for i, a := range group {
+			expanded[i] = expandAttr(a)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(expanded...)}
+	case slog.KindAny:
+		err, ok := v.Any().(error)
+		if !ok {
+			break
+		}
+		for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(inner) {
+			switch inner.(type) {
+			case *StackErr, *Box:
+				attrs := append([]slog.Attr{slog.String("message", err.Error())}, SlogAttrs(inner)...)
+				return slog.Attr{Key: attr.Key, Value: slog.GroupValue(attrs...)}
+			}
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: v}
+}
@@ -0,0 +1,89 @@
+package errbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	ShowStack(true)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	b := NewBox()
+	b.PushIf(WithField(fmt.Errorf("boom"), "order_id", 42), "processing order")
+	b.PushIf(fmt.Errorf("bang"), "")
+	logger.Error("failed", "err", b)
+
+	var record struct {
+		Err struct {
+			Count int
+			First struct {
+				Cause       string
+				Annotations []string
+				Frames      []string
+				Fields      map[string]int
+			} `json:"1"`
+			Second struct {
+				Cause string
+			} `json:"2"`
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("cannot parse log: %v", err)
+	}
+	first := record.Err.First
+	if record.Err.Count != 2 || first.Cause != "boom" || len(first.Annotations) != 1 || len(first.Frames) != 1 || first.Fields["order_id"] != 42 {
+		t.Errorf("unexpected structure of the first error: %s", buf.String())
+	}
+	if record.Err.Second.Cause != "bang" {
+		t.Errorf("unexpected structure of the second error: %s", buf.String())
+	}
+}
+
+func TestSlogAttrs(t *testing.T) {
+	attrs := SlogAttrs(Annotate(fmt.Errorf("boom"), "processing order"))
+	if len(attrs) < 2 || attrs[0].Key != "cause" || attrs[0].Value.String() != "boom" || attrs[1].Key != "annotations" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	if attrs := SlogAttrs(Append(nil, fmt.Errorf("boom"))); attrs[0].Key != "count" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	if SlogAttrs(nil) != nil {
+		t.Errorf("expected no attributes for nil error")
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(SlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	err := WithField(Annotate(fmt.Errorf("boom"), "processing order"), "order_id", 42)
+	logger.With("request", "r1").WithGroup("http").Error("failed", "err", fmt.Errorf("handling request: %w", err), "status", 500)
+
+	var entry struct {
+		HTTP struct {
+			Err struct {
+				Message     string
+				Cause       string
+				Annotations []string
+				Fields      map[string]interface{}
+			}
+			Status int
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	e := entry.HTTP.Err
+	if e.Cause != "boom" || len(e.Annotations) != 1 || e.Fields["order_id"] != float64(42) || entry.HTTP.Status != 500 {
+		t.Errorf("expected expanded error, got %s", buf.String())
+	}
+	if e.Message == "" {
+		t.Errorf("expected message of the wrapping error, got %s", buf.String())
+	}
+}
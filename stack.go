@@ -1,72 +1,240 @@
 package errbox
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 // StackErr is an error with stack trace.
 type StackErr struct {
+	mu         sync.Mutex             // protects fields, tags, suppressed errors and classification
 	cause      error                  // the original error
 	annotation []stackAnnotation      // annotation of the error
 	fields     map[string]interface{} // optional fields attached to the error via Fields.
+	tags       map[string]string      // optional tags attached to the error via Box.PushTagged
+	stack      toggle                 // overrides the HideStack option (see ShowStack) for this error
+	panicStack []byte                 // full goroutine stack, if the error was created from a panic
+	origins    []*Origin              // stacks of goroutines which launched the goroutine where the error happened
+	group      string                 // name of the group, if the cause is a sub-box (see Box.Group)
+	suppressed []error                // errors which happened during cleanup after this error
+	unsampled  bool                   // places in code are not recorded, see SampleStacks
+
+	// classification of the error, protected by mu
+	userMessage string   // message which is safe to show to the end user
+	hints       []string // actionable advice for the user
+	severity    Severity // how serious the error is, zero if not set
+	code        string   // machine readable identifier of the failure
+	kind        Kind     // category of the error
+	retryable   toggle   // can the operation be retried
 }
 
 // stackAnnotation is the annotation of the error.
 type stackAnnotation struct {
 	// what happened?
 	message string
+	args    []interface{} // arguments of the message, if it is lazy
+	lazy    bool          // message is a format, which was not applied to args yet
 	// where did it happen?
 	file     string
 	function string
 	line     int
+	pc       uintptr
+	// how many times in a row did it happen? zero means once
+	repeated int
 }
 
 // Annotate returns back an error annotated with stack trace (of type *StackErr), or nil, if the first parameter was nil.
 //
 // Repeated call of Annotate on the same error only add the annotation to the (already existing) error.
 //
-// Call of Annotate on error which is a *Box  annotates all errors.
+// Call of Annotate on error which is a *Box  annotates all errors, including errors in groups (see Box.Group).
 //
 // If the message is not empty string, it is added to the stack. The message is formatting string used by fmt.Sprintf,
-// and args... is a variadic parameter which is also provided to the fmt.Sprintf.
+// and args... is a variadic parameter which is also provided to the fmt.Sprintf. If there are no args, the message
+// is used as it is (it is not formatted, so "%" does not need to be escaped).
 func Annotate(err error, message string, args ...interface{}) error {
+	return annotateErr(3, err, message, args...)
+}
+
+// DeferAnnotate annotates the error pointed to by the err (see Annotate), if it is not nil.
+// It is meant to be deferred at the top of a function with a named error return value:
+//
+//	func load(name string) (err error) {
+//		defer errbox.DeferAnnotate(&err, "loading %s", name)
+//		// ...
+//	}
+func DeferAnnotate(err *error, message string, args ...interface{}) {
+	if isNil(*err) {
+		return
+	}
+	*err = annotateErr(3, *err, message, args...)
+}
+
+// AnnotateIf annotates the err (see Annotate) if the cond is true, otherwise returns the err unchanged.
+func AnnotateIf(cond bool, err error, message string, args ...interface{}) error {
+	if !cond {
+		return err
+	}
+	return annotateErr(3, err, message, args...)
+}
+
+// AnnotateUnless annotates the err (see Annotate) unless it is the target (think errors.Is), in which case
+// the err is returned unchanged. This avoids noisy wrapping of expected errors, like io.EOF:
+//
+//	return errbox.AnnotateUnless(err, io.EOF, "reading header")
+func AnnotateUnless(err error, target error, message string, args ...interface{}) error {
+	if isNil(err) {
+		return nil
+	}
+	if errors.Is(err, target) {
+		return err
+	}
+	return annotateErr(3, err, message, args...)
+}
+
+// AnnotateLazy works like Annotate, but the message is formatted only when it is needed (typically, when
+// the error is printed out), instead of immediately. It is meant for hot paths which wrap errors, and then
+// frequently discard them.
+//
+// The args are kept by the error, and they should NOT be modified after the call.
+func AnnotateLazy(err error, message string, args ...interface{}) error {
+	if isNil(err) {
+		return nil
+	}
+	if b, ok := err.(*Box); ok {
+		for _, e := range b.leaves() {
+			e.annotateLazy(2, message, args...)
+		}
+		return b
+	}
+	this, isNew := withStack(err)
+	this.annotateLazy(2, message, args...)
+	if isNew {
+		created(this)
+	}
+	return this
+}
+
+// annotateErr implements Annotate, skip has the same meaning as in runtime.Caller, counted from annotate.
+func annotateErr(skip int, err error, message string, args ...interface{}) error {
 	// return on no error
-	if err == nil {
+	if isNil(err) {
 		return nil
 	}
 
 	// what if the err is actually *Box?
 	// then we annotate all errors in the box
 	if b, ok := err.(*Box); ok {
-		for i := range b.errLis {
-			b.errLis[i].annotate(2, message, args...)
+		for _, e := range b.leaves() {
+			e.annotate(skip, message, args...)
 		}
 		return b
 	}
 
 	// annotate this error (give it stack trace and additional message
-	this := WithStack(err)
-	this.annotate(2, message, args...)
+	this, isNew := withStack(err)
+	this.annotate(skip, message, args...)
+	if isNew {
+		created(this)
+	}
 	return this
 }
 
 // WithStack returns the error as StackErr error, or converts the err to a new StackErr if possible.
-// Returns nil if err is nil.
+// Returns nil if err is nil, or if it is a nil pointer stored in the error interface (like a nil *os.PathError).
+//
+// The new StackErr is reported to the Observer and to hooks registered by OnCreate.
 func WithStack(err error) *StackErr {
-	if err == nil {
-		return nil
+	be, isNew := withStack(err)
+	if isNew {
+		created(be)
+	}
+	return be
+}
+
+// withStack implements WithStack, but it does not report the new StackErr; the second value is true if the StackErr
+// is new, so that callers can report it (see created) once they finish it, and outside of any lock.
+func withStack(err error) (*StackErr, bool) {
+	if isNil(err) {
+		return nil, false
 	}
 	if be, ok := err.(*StackErr); ok {
-		return be
+		return be, false
 	}
 	be := new(StackErr)
 	be.cause = err
+	if currentOptions().StampBuildInfo {
+		be.stampBuildInfo()
+	}
+	be.inheritFields()
+	return be, true
+}
+
+// Inspect returns the err as StackErr, like WithStack, but a new StackErr is not reported to the Observer, nor to hooks
+// registered by OnCreate. It is meant for exporters and loggers, which read errors, but do not take them over:
+//
+//	for k, v := range errbox.Inspect(err).CopyFields() {
+//		span.SetAttributes(attribute.String(k, fmt.Sprint(v)))
+//	}
+//
+// Returns nil if err is nil.
+func Inspect(err error) *StackErr {
+	be, _ := withStack(err)
 	return be
 }
 
+// Restore returns a new StackErr with the cause, annotated by the messages (oldest first), or nil, if the cause
+// is nil. The annotations have no place in code recorded. Restore is meant for integrations which reconstruct
+// errors received from other processes, where the places are not known, or not meaningful.
+func Restore(cause error, annotations ...string) *StackErr {
+	if cause == nil {
+		return nil
+	}
+	be := &StackErr{cause: cause}
+	for _, message := range annotations {
+		be.annotation = append(be.annotation, stackAnnotation{message: message})
+	}
+	return be
+}
+
+// Fingerprint returns a string which identifies the error by its content: the cause message, and the place
+// where the error was first annotated (if it was annotated). Two errors with the same fingerprint are
+// the same failure, which happened at the same place (typically, repeatedly in a retry loop).
+//
+// Fingerprint of a *Box consists of fingerprints of all its errors, one per line. An error for which places
+// in code were not recorded (see SampleStacks) can not be told apart from the same failure at another place,
+// so its fingerprint is unique to the error.
+// Returns empty string if the err is nil.
+func Fingerprint(err error) string {
+	if isNil(err) {
+		return ""
+	}
+	if b, ok := err.(*Box); ok {
+		var fps []string
+		for _, e := range Errors(b) {
+			fps = append(fps, Fingerprint(e))
+		}
+		return strings.Join(fps, "\n")
+	}
+	be := Inspect(err)
+	for _, anno := range be.annotations() {
+		if anno.line > 0 {
+			return fmt.Sprintf("%s @ %s:%d", be.cause, anno.file, anno.line)
+		}
+	}
+	be.mu.Lock()
+	unsampled := be.unsampled
+	be.mu.Unlock()
+	if unsampled {
+		return fmt.Sprintf("%s @ %p", be.cause, be)
+	}
+	return be.cause.Error()
+}
+
 // Cause returns cause of the error.
 //
 // It the error is nil, nil is returned.
@@ -77,52 +245,125 @@ func WithStack(err error) *StackErr {
 //
 // Otherwise, err is returned.
 func Cause(err error) error {
-	if err == nil {
+	if isNil(err) {
 		return nil
 	}
 	if e, ok := err.(*StackErr); ok {
+		if e.group != "" {
+			return Cause(e.cause) // the first error of the group
+		}
 		return e.cause
 	}
 	if e, ok := err.(*Box); ok {
-		if len(e.errLis) == 0 {
-			return nil
-		}
-		return Cause(e.errLis[0])
+		return Cause(e.First())
 	}
 	return err
 }
 
-// Fields returns a map, which can be used to store or fetch anything. Typically, you would use it as follows:
-//   stacked := WithStack(err)
-//   fields := stacked.Fields()
-//   fields["whatever"] = "whatever"  // set it to string
-//   x := stacked.StringField("whatever")  // get it back
-func (b *StackErr) Fields() map[string]interface{} {
-	if b.fields == nil {
-		b.fields = make(map[string]interface{})
+// RootCause returns the innermost error of the err: unlike Cause, it does not stop at the cause of the StackErr,
+// but unwraps the whole chain (see errors.Unwrap), including other StackErrs and errors wrapped by fmt.Errorf with %w.
+// Errors which wrap more errors (like errors.Join) are followed by the first of them.
+//
+// If the error is Box, root cause of the first error in the box is returned. If the error is nil, nil is returned.
+func RootCause(err error) error {
+	for !isNil(err) {
+		var next error
+		switch e := err.(type) {
+		case *Box:
+			if next = Cause(e); next == nil {
+				return nil // empty box
+			}
+		case interface{ Unwrap() []error }:
+			if errs := e.Unwrap(); len(errs) > 0 {
+				next = errs[0]
+			}
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		}
+		if next == nil {
+			return err
+		}
+		err = next
 	}
-	return b.fields
+	return nil
 }
 
-// StringField attempts to access a field, convert it to a string, and return it.
-// The function returns empty string if the field was not found, or if the value could not be converted to string.
-func (b *StackErr) StringField(name string) string {
-	m := b.Fields()
-	i, ok := m[name]
-	if !ok {
-		return ""
+// isEmptyGroup returns true if the error is a group (see Box.Group) without any errors.
+func (b *StackErr) isEmptyGroup() bool {
+	return b.group != "" && b.cause.(*Box).IsEmpty()
+}
+
+// clone returns a copy of the error, which does not share annotations, fields or tags with the error.
+func (b *StackErr) clone() *StackErr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := &StackErr{
+		cause:      b.cause,
+		annotation: append([]stackAnnotation(nil), b.annotation...),
+		stack:      b.stack,
+		panicStack: b.panicStack,
+		origins:    append([]*Origin(nil), b.origins...),
+		group:      b.group,
+		suppressed: append([]error(nil), b.suppressed...),
+		unsampled:  b.unsampled,
+
+		userMessage: b.userMessage,
+		hints:       append([]string(nil), b.hints...),
+		severity:    b.severity,
+		code:        b.code,
+		kind:        b.kind,
+		retryable:   b.retryable,
+	}
+	if b.group != "" {
+		c.cause = b.cause.(*Box).Snapshot()
+	}
+	if b.fields != nil {
+		c.fields = make(map[string]interface{}, len(b.fields))
+		for k, v := range b.fields {
+			c.fields[k] = v
+		}
 	}
-	s, ok := i.(string)
-	if ok {
-		return s
+	if b.tags != nil {
+		c.tags = make(map[string]string, len(b.tags))
+		for k, v := range b.tags {
+			c.tags[k] = v
+		}
 	}
-	return ""
+	return c
+}
+
+// WithStackOutput overrides the HideStack option (see ShowStack) for this error, and returns the error back.
+//
+// Why is this useful: the same error can be written with full stack trace to internal logs, and without it
+// to the API response, see Box.WithStackOutput.
+func (b *StackErr) WithStackOutput(show bool) *StackErr {
+	b.stack = toggleOf(show)
+	return b
 }
 
 // Error implements the Error interface
 func (b *StackErr) Error() string {
+	return b.render(b.stack.resolve(!currentOptions().HideStack))
+}
+
+// render returns the error as a string, showStack controls if the stack trace is printed out.
+func (b *StackErr) render(showStack bool) string {
+	if b.group != "" {
+		var sb strings.Builder
+		sb.WriteString(b.group + ":\n")
+		writeIndented(&sb, b.cause.(*Box).render(toggleOf(showStack)))
+		return sb.String()
+	}
+
+	showPanic := showStack && len(b.panicStack) > 0
+	showOrigins := showStack && len(b.origins) > 0
+	suppressed := b.Suppressed()
+	hints := b.ownHints()
+	code, hasCode := b.ownCode()
+	annotation := b.annotations()
+
 	// if no annotation is found, return the original error
-	if len(b.annotation) == 0 {
+	if len(annotation) == 0 && !showPanic && !showOrigins && len(suppressed) == 0 && len(hints) == 0 && !hasCode {
 		return b.cause.Error()
 	}
 
@@ -132,12 +373,17 @@ func (b *StackErr) Error() string {
 	dThis := " +--"
 	dEmpty := "    "
 
-	ln := len(b.annotation) - 1
+	ln := len(annotation) - 1
 	sb.WriteString(fmt.Sprintf("%s\n", b.cause))
-	for i, anno := range b.annotation {
+	for i, anno := range annotation {
 		delim := dThis
-		if anno.message != "" {
-			sb.WriteString(fmt.Sprintf("%s> %s\n", delim, anno.message))
+		counter := ""
+		if anno.repeated > 0 {
+			counter = fmt.Sprintf(" (x%d)", anno.repeated+1)
+		}
+		if message := anno.text(); message != "" {
+			sb.WriteString(fmt.Sprintf("%s> %s%s\n", delim, message, counter))
+			counter = ""
 			if i < ln {
 				delim = dNext
 			} else {
@@ -145,60 +391,310 @@ func (b *StackErr) Error() string {
 			}
 		}
 		if showStack && anno.line > 0 {
-			sb.WriteString(fmt.Sprintf("%s@ %s:%d (%s)\n", delim, anno.file, anno.line, anno.function))
+			sb.WriteString(fmt.Sprintf("%s@ %s (%s)%s\n", delim, location(anno.file, anno.line), anno.function, counter))
+		}
+	}
+	if hasCode {
+		sb.WriteString(fmt.Sprintf(" code: %s\n", formatCode(code)))
+	}
+	if showPanic {
+		sb.WriteString(" panic stack:\n")
+		writeIndented(&sb, panicStack(b.panicStack))
+	}
+	if showOrigins {
+		for _, o := range b.origins {
+			sb.WriteString(" launched from:\n")
+			for _, frame := range o.frames {
+				sb.WriteString(fmt.Sprintf("    @ %s (%s)\n", location(frame.file, frame.line), frame.function))
+			}
+		}
+	}
+	for _, hint := range hints {
+		sb.WriteString(fmt.Sprintf(" hint: %s\n", hint))
+	}
+	for _, err := range suppressed {
+		sb.WriteString(" suppressed:\n")
+		if se, ok := err.(*StackErr); ok {
+			writeIndented(&sb, se.render(se.stack.resolve(showStack)))
+		} else {
+			writeIndented(&sb, err.Error())
 		}
 	}
 	return sb.String()
 }
 
+// writeIndented writes all lines of the text to the sb, indented by four spaces.
+func writeIndented(sb *strings.Builder, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line != "" {
+			sb.WriteString("    ")
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
+
+// AddSuppressed adds the err as a suppressed error: a secondary error which happened during cleanup after this
+// (primary) error, like a failed Close or rollback. Suppressed errors are printed out in a "suppressed" section,
+// and the error matches them in errors.Is and errors.As. Nil err is ignored.
+func (b *StackErr) AddSuppressed(err error) {
+	if isNil(err) {
+		return
+	}
+	cycle := false
+	Walk(err, func(e error) bool {
+		cycle = e == error(b)
+		return !cycle
+	})
+	if cycle {
+		return // the error cannot suppress itself
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.suppressed = append(b.suppressed, err)
+}
+
+// Suppressed returns a copy of the list of suppressed errors (see AddSuppressed), or nil, if there are none.
+func (b *StackErr) Suppressed() []error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.suppressed) == 0 {
+		return nil
+	}
+	return append([]error(nil), b.suppressed...)
+}
+
+// Is makes the error match its suppressed errors, see errors.Is. The cause is matched by errors.Is itself.
+func (b *StackErr) Is(target error) bool {
+	for _, err := range b.Suppressed() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As makes the error match its suppressed errors, see errors.As. The cause is matched by errors.As itself.
+func (b *StackErr) As(target interface{}) bool {
+	for _, err := range b.Suppressed() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // Unwrap implements errors.Unwrap interface.
 func (b *StackErr) Unwrap() error {
 	return b.cause
 }
 
+// Callers returns program counters of places in code where the error was annotated, the first annotation first
+// (that is, the innermost call first, like runtime.Callers). Use runtime.CallersFrames to translate them to
+// functions, files and lines. Annotations which do not have a place in code recorded (see Restore and FromJSON)
+// are skipped.
+func (b *StackErr) Callers() []uintptr {
+	var pcs []uintptr
+	for _, anno := range b.annotations() {
+		if anno.pc != 0 {
+			pcs = append(pcs, anno.pc)
+		}
+	}
+	return pcs
+}
+
+// Annotation is an annotation of a StackErr: the message, and the place in code where the error was annotated.
+// See StackErr.Annotations.
+type Annotation struct {
+	Message  string `json:"message,omitempty"`  // message of the annotation, empty if the error was annotated without a message
+	File     string `json:"file,omitempty"`     // file name (see the FilePrefix option), empty if the place is not known (see Restore)
+	Line     int    `json:"line,omitempty"`     // line number, zero if the place is not known
+	Function string `json:"function,omitempty"` // name of the function
+	Repeated int    `json:"repeated,omitempty"` // how many more times in a row the error was annotated at the same place, with the same message
+}
+
+// Annotations returns a copy of annotations of the error, the first annotation (the innermost call) first,
+// so that tooling can inspect the chain without parsing the output of Error. Lazy messages (see AnnotateLazy)
+// are formatted.
+func (b *StackErr) Annotations() []Annotation {
+	annotation := b.annotations()
+	if len(annotation) == 0 {
+		return nil
+	}
+	annotations := make([]Annotation, len(annotation))
+	for i, anno := range annotation {
+		annotations[i] = anno.export()
+	}
+	return annotations
+}
+
+// annotations returns a copy of annotations of the error, taken under the lock.
+func (b *StackErr) annotations() []stackAnnotation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]stackAnnotation(nil), b.annotation...)
+}
+
+// Depth returns the number of annotations of the error (see Annotations). Repeated annotations (made in a row
+// at the same place, with the same message) are counted once.
+func (b *StackErr) Depth() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.annotation)
+}
+
+// LastAnnotation returns the last annotation of the error (the outermost one), and true, or false if the error
+// has no annotations. Middleware can use it to avoid annotating an error twice at the same layer:
+//
+//	if last, ok := se.LastAnnotation(); !ok || last.Function != "myapp.(*Server).Handle" {
+//		err = errbox.Annotate(err, "handling request")
+//	}
+func (b *StackErr) LastAnnotation() (Annotation, bool) {
+	b.mu.Lock()
+	if len(b.annotation) == 0 {
+		b.mu.Unlock()
+		return Annotation{}, false
+	}
+	last := b.annotation[len(b.annotation)-1]
+	b.mu.Unlock()
+	return last.export(), true
+}
+
+// export returns the annotation as Annotation.
+func (a stackAnnotation) export() Annotation {
+	return Annotation{
+		Message:  a.text(),
+		File:     a.file,
+		Line:     a.line,
+		Function: a.function,
+		Repeated: a.repeated,
+	}
+}
+
 // annotate adds the message to the original error
 func (b *StackErr) annotate(skip int, message string, args ...interface{}) {
-	// User code is two stack frames up, as this is called from Annotate
-	pc, file, line, ok := runtime.Caller(skip)
-	if !ok {
+	b.annotateWith(skip+1, stackAnnotation{message: formatMessage(message, args)})
+}
+
+// formatMessage formats the message by fmt.Sprintf, unless there are no args: then the message is returned
+// as it is, so that messages with % characters (like "disk 95% full") are not mangled, and annotations without
+// a message do not pay for formatting.
+func formatMessage(message string, args []interface{}) string {
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// annotateLazy adds the message to the original error, the message is formatted only when it is needed.
+func (b *StackErr) annotateLazy(skip int, format string, args ...interface{}) {
+	b.annotateWith(skip+1, stackAnnotation{message: format, args: args, lazy: true})
+}
+
+// annotateWith adds the annotation to the original error, after it fills in where did it happen.
+// The skip has the same meaning as in runtime.Caller.
+func (b *StackErr) annotateWith(skip int, annotation stackAnnotation) {
+	// decide if places in code are recorded for this error, see SampleStacks
+	b.mu.Lock()
+	if len(b.annotation) == 0 && !sampleStack() {
+		b.unsampled = true
+	}
+	unsampled := b.unsampled
+	b.mu.Unlock()
+	if !unsampled {
+		// runtime.Callers (unlike runtime.Caller) returns a program counter which can be passed to
+		// runtime.CallersFrames, even if the caller was inlined, see Callers
+		var pcs [1]uintptr
+		if runtime.Callers(skip+1, pcs[:]) == 0 {
+			return
+		}
+		pc := pcs[0]
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+
+		// prepare the annotation
+		annotation.file = cleanFile(frame.File)
+		annotation.line = frame.Line
+
+		// get the function
+		annotation.pc = pc
+		annotation.function = funcNameForPC(pc)
+	}
+	// collapse it with the previous annotation, if it is the same (typically, a retry loop); lazy messages
+	// are formatted without the lock, because their args may print out the error
+	b.mu.Lock()
+	ln := len(b.annotation)
+	var previous stackAnnotation
+	if ln > 0 {
+		previous = b.annotation[ln-1]
+	}
+	b.mu.Unlock()
+	same := ln > 0 && previous.sameAs(annotation)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if same && len(b.annotation) == ln {
+		b.annotation[ln-1].repeated++
 		return
 	}
+	// append it to the error
+	b.annotation = append(b.annotation, annotation)
+}
+
+// text returns the message of the annotation, formatting it if it is lazy.
+func (a stackAnnotation) text() string {
+	if a.lazy {
+		return formatMessage(a.message, a.args)
+	}
+	return a.message
+}
+
+// sameAs returns true if both annotations have the same message and were made at the same place.
+// Annotations without a recorded place (see SampleStacks) are never the same.
+func (a stackAnnotation) sameAs(other stackAnnotation) bool {
+	return a.line != 0 && a.file == other.file && a.line == other.line && a.function == other.function && a.text() == other.text()
+}
 
-	// clean the file
-	if filePrefix != "" {
+// cleanFile removes the FilePrefix from the file, and applies the Sanitize option to it.
+func cleanFile(file string) string {
+	opts := currentOptions()
+	if opts.FilePrefix != "" {
 		file = filepath.ToSlash(file)
-		idx := strings.Index(file, filePrefix)
+		idx := strings.Index(file, opts.FilePrefix)
 		if idx > -1 {
-			idx = idx + len(filePrefix)
+			idx = idx + len(opts.FilePrefix)
 			file = file[idx:]
 		}
 	}
-
-	// prepare the annotation
-	annotation := stackAnnotation{
-		message: fmt.Sprintf(message, args...),
-		file:    file,
-		line:    line,
+	if opts.Sanitize != nil {
+		file = opts.Sanitize(file)
 	}
+	return file
+}
 
-	// get the function
-	f := runtime.FuncForPC(pc)
-	if f != nil {
-		annotation.function = shortFuncName(f)
+// funcNames caches results of funcNameForPC, keyed by the program counter.
+var funcNames sync.Map
+
+// funcNameForPC returns the short name (see shortFuncName) of the function containing the pc,
+// or empty string, if the function is not known.
+func funcNameForPC(pc uintptr) string {
+	if name, ok := funcNames.Load(pc); ok {
+		return name.(string)
 	}
-	// append it to the error
-	b.annotation = append(b.annotation, annotation)
+	var name string
+	if frame, _ := runtime.CallersFrames([]uintptr{pc}).Next(); frame.Function != "" {
+		name = shortFuncName(frame.Function)
+	}
+	funcNames.Store(pc, name)
+	return name
 }
 
 // this comes from https://github.com/palantir/stacktrace/blob/master/stacktrace.go
 // props to them!
-func shortFuncName(f *runtime.Func) string {
-	// f.Name() is like one of these:
+func shortFuncName(longName string) string {
+	// longName is like one of these:
 	// - "github.com/palantir/shield/package.FuncName"
 	// - "github.com/palantir/shield/package.Receiver.MethodName"
 	// - "github.com/palantir/shield/package.(*PtrReceiver).MethodName"
-	longName := f.Name()
-
 	withoutPath := longName[strings.LastIndex(longName, "/")+1:]
 	withoutPackage := withoutPath[strings.Index(withoutPath, ".")+1:]
 
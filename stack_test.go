@@ -0,0 +1,190 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDeferAnnotate(t *testing.T) {
+	ShowStack(true)
+
+	load := func(name string, fail bool) (err error) {
+		defer DeferAnnotate(&err, "loading %s", name)
+		if fail {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}
+	if err := load("config.yaml", false); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	err := load("config.yaml", true)
+	if s := err.Error(); !strings.Contains(s, "> loading config.yaml") || !strings.Contains(s, "(TestDeferAnnotate.func1)") {
+		t.Errorf("unexpected annotation: %s", s)
+	}
+}
+
+func TestAnnotateIfUnless(t *testing.T) {
+	err := fmt.Errorf("boom")
+	if AnnotateIf(false, err, "skipped") != err {
+		t.Errorf("expected the error to be returned unchanged")
+	}
+	if s := AnnotateIf(true, err, "annotated").Error(); !strings.Contains(s, "> annotated") {
+		t.Errorf("expected annotation: %s", s)
+	}
+	if AnnotateUnless(io.EOF, io.EOF, "skipped") != io.EOF {
+		t.Errorf("expected io.EOF to be returned unchanged")
+	}
+	if s := AnnotateUnless(err, io.EOF, "annotated").Error(); !strings.Contains(s, "> annotated") {
+		t.Errorf("expected annotation: %s", s)
+	}
+	if AnnotateUnless(nil, io.EOF, "nil") != nil {
+		t.Errorf("expected nil error to stay nil")
+	}
+}
+
+type countingStringer struct{ calls *int }
+
+func (c countingStringer) String() string {
+	*c.calls++
+	return "formatted"
+}
+
+func TestAnnotateLazy(t *testing.T) {
+	ShowStack(true)
+	calls := 0
+	arg := countingStringer{&calls}
+
+	err := AnnotateLazy(fmt.Errorf("boom"), "value %s", arg)
+	b := NewBox()
+	b.PushIfLazy(fmt.Errorf("bang"), "value %s", arg)
+	if calls != 0 {
+		t.Errorf("expected no formatting yet, got %d calls", calls)
+	}
+	if s := err.Error(); !strings.Contains(s, "> value formatted") || !strings.Contains(s, "(TestAnnotateLazy)") {
+		t.Errorf("unexpected annotation: %s", s)
+	}
+	if s := b.Error(); !strings.Contains(s, "> value formatted") || !strings.Contains(s, "(TestAnnotateLazy)") {
+		t.Errorf("unexpected annotation: %s", s)
+	}
+}
+
+func TestSuppressed(t *testing.T) {
+	ShowStack(true)
+	rollbackErr := errors.New("rollback failed")
+
+	err := WithStack(Annotate(fmt.Errorf("insert failed"), "saving order"))
+	err.AddSuppressed(Annotate(rollbackErr, "rolling back"))
+	err.AddSuppressed(nil)
+
+	if !errors.Is(err, rollbackErr) || len(err.Suppressed()) != 1 {
+		t.Errorf("expected the suppressed error to be reachable: %v", err.Suppressed())
+	}
+	if s := err.Error(); !strings.Contains(s, " suppressed:\n    rollback failed\n     +--> rolling back") {
+		t.Errorf("unexpected rendering: %s", s)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	err := Restore(errors.New("connection refused"), "dialing db", "loading users")
+	want := "connection refused\n +--> dialing db\n +--> loading users\n"
+	if s := err.Error(); s != want {
+		t.Errorf("unexpected rendering:\n%s", s)
+	}
+	if Restore(nil, "x") != nil {
+		t.Errorf("expected nil for nil cause")
+	}
+}
+
+func TestCallers(t *testing.T) {
+	err := WithStack(Annotate(errors.New("boom"), "first"))
+	Annotate(err, "second")
+	pcs := err.Callers()
+	if len(pcs) != 2 {
+		t.Fatalf("expected two callers, got %d", len(pcs))
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	if !strings.HasSuffix(frame.Function, "TestCallers") {
+		t.Errorf("unexpected function %q", frame.Function)
+	}
+	if len(Restore(errors.New("boom"), "remote").Callers()) != 0 {
+		t.Errorf("expected no callers of restored error")
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	root := errors.New("root")
+	err := Annotate(fmt.Errorf("reading: %w", Annotate(fmt.Errorf("parsing: %w", root), "inner")), "outer")
+	if got := RootCause(err); got != root {
+		t.Errorf("expected the root, got %v", got)
+	}
+	box := NewBox()
+	box.PushIf(errors.Join(err, errors.New("other")), "")
+	if got := RootCause(box); got != root {
+		t.Errorf("expected the root of the first error in the box, got %v", got)
+	}
+	if RootCause(nil) != nil || RootCause(NewBox()) != nil {
+		t.Errorf("expected nil")
+	}
+}
+
+func TestAnnotateWithoutArgs(t *testing.T) {
+	err := Annotate(errors.New("boom"), "disk 95% full")
+	err = AnnotateLazy(err, "load at 100%")
+	err = Annotate(err, "%d%% done", 50)
+	msg := err.Error()
+	for _, want := range []string{"disk 95% full", "load at 100%", "50% done"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "MISSING") {
+		t.Errorf("unexpected formatting artifacts in:\n%s", msg)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	err := WithStack(errors.New("boom"))
+	if err.Annotations() != nil {
+		t.Errorf("expected no annotations")
+	}
+	for i := 0; i < 3; i++ {
+		_ = Annotate(err, "retrying")
+	}
+	_ = AnnotateLazy(err, "attempt %d", 4)
+
+	annotations := err.Annotations()
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations, got %+v", annotations)
+	}
+	first := annotations[0]
+	if first.Message != "retrying" || first.Repeated != 2 || first.Function != "TestAnnotations" ||
+		!strings.HasSuffix(first.File, "stack_test.go") || first.Line == 0 {
+		t.Errorf("unexpected annotation %+v", first)
+	}
+	if annotations[1].Message != "attempt 4" {
+		t.Errorf("expected the lazy message to be formatted, got %+v", annotations[1])
+	}
+	if restored := Restore(errors.New("boom"), "remote").Annotations(); restored[0].Message != "remote" || restored[0].Line != 0 {
+		t.Errorf("unexpected restored annotation %+v", restored[0])
+	}
+}
+
+func TestDepthLastAnnotation(t *testing.T) {
+	err := WithStack(errors.New("boom"))
+	if _, ok := err.LastAnnotation(); ok || err.Depth() != 0 {
+		t.Errorf("expected no annotations")
+	}
+	_ = Annotate(err, "inner")
+	for i := 0; i < 2; i++ {
+		_ = Annotate(err, "outer")
+	}
+	last, ok := err.LastAnnotation()
+	if !ok || last.Message != "outer" || last.Repeated != 1 || err.Depth() != 2 {
+		t.Errorf("unexpected last annotation %+v, depth %d", last, err.Depth())
+	}
+}
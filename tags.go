@@ -0,0 +1,49 @@
+package errbox
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tags returns a copy of tags attached to the error when it was pushed into a box (see Box.PushTagged),
+// or nil, if the error has no tags.
+func (b *StackErr) Tags() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.tags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(b.tags))
+	for k, v := range b.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// tag attaches the tags to the error. Tags which already exist are overwritten.
+func (b *StackErr) tag(tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tags == nil {
+		b.tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		b.tags[k] = v
+	}
+}
+
+// formatTags returns the tags sorted by name, like " (file=data.csv, shard=3)", or empty string if there are no tags.
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return " (" + strings.Join(pairs, ", ") + ")"
+}
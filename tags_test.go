@@ -0,0 +1,24 @@
+package errbox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPushTagged(t *testing.T) {
+	b := NewBox()
+	b.PushTagged(fmt.Errorf("one"), map[string]string{"shard": "1"}, "")
+	b.PushTagged(fmt.Errorf("two"), map[string]string{"shard": "2", "file": "b.csv"}, "")
+	b.PushTagged(fmt.Errorf("three"), map[string]string{"shard": "2"}, "")
+	if b.PushTagged(nil, map[string]string{"shard": "3"}, "") {
+		t.Errorf("nil error should not be pushed")
+	}
+
+	if n := b.Tagged("shard", "2").Len(); n != 2 {
+		t.Errorf("expected 2 errors from shard 2, got %d", n)
+	}
+	if s := b.Error(); !strings.Contains(s, "# 2 (file=b.csv, shard=2)") {
+		t.Errorf("expected tags in the output: %s", s)
+	}
+}
@@ -0,0 +1,107 @@
+package errbox
+
+// NodeKind is the kind of a Node.
+type NodeKind int
+
+const (
+	// NodeCause is a leaf: an error which does not wrap any other error.
+	NodeCause NodeKind = iota
+	// NodeStack is a StackErr: its annotations are in the node, its cause is the only child.
+	NodeStack
+	// NodeWrap is an error which wraps another error (see errors.Unwrap), like fmt.Errorf with %w.
+	// The wrapped error is the only child.
+	NodeWrap
+	// NodeJoin is an error which wraps more errors, like errors.Join. The wrapped errors are children.
+	NodeJoin
+	// NodeBox is a Box, its errors are children.
+	NodeBox
+	// NodeGroup is a named group of a box (see Box.Group), its errors are children.
+	NodeGroup
+)
+
+// nodeKindNames are names of node kinds, see NodeKind.String.
+var nodeKindNames = [...]string{"cause", "stack", "wrap", "join", "box", "group"}
+
+// String returns the name of the kind, like "box".
+func (k NodeKind) String() string {
+	if k < 0 || int(k) >= len(nodeKindNames) {
+		return "unknown"
+	}
+	return nodeKindNames[k]
+}
+
+// Node is a node of the tree of an error, see Tree.
+type Node struct {
+	Kind        NodeKind
+	Err         error        // the error represented by the node
+	Message     string       // message of a cause, or of a wrapping error; name of a group; empty for boxes
+	Annotations []Annotation // annotations of a StackErr, see StackErr.Annotations
+	Suppressed  []*Node      // suppressed errors of a StackErr, see StackErr.AddSuppressed
+	Children    []*Node
+}
+
+// Tree returns the structure of the err as a tree of typed nodes: boxes and groups are branches, StackErrs and
+// other wrapping errors are chains (nodes with one child), and causes are leaves. It lets renderers, exporters
+// and tests consume the structure of errors, instead of parsing their messages:
+//
+//	for _, node := range errbox.Tree(box).Children {
+//		fmt.Println(node.Kind, len(node.Annotations))
+//	}
+//
+// Returns nil if the err is nil. A box which (indirectly) contains itself is represented by a node
+// without children, when it is found the second time.
+func Tree(err error) *Node {
+	var seen []*Box
+	return tree(err, &seen)
+}
+
+// tree implements Tree, seen contains boxes on the path from the root.
+func tree(err error, seen *[]*Box) *Node {
+	if isNil(err) {
+		return nil
+	}
+	node := &Node{Err: err}
+	switch e := err.(type) {
+	case *Box:
+		node.Kind = NodeBox
+		for _, s := range *seen {
+			if s == e {
+				return node
+			}
+		}
+		*seen = append(*seen, e)
+		defer func() { *seen = (*seen)[:len(*seen)-1] }()
+		for _, inner := range Errors(e) {
+			node.Children = append(node.Children, tree(inner, seen))
+		}
+	case *StackErr:
+		if e.group != "" {
+			node.Kind, node.Message = NodeGroup, e.group
+			node.Children = tree(e.cause, seen).Children
+			return node
+		}
+		node.Kind = NodeStack
+		node.Annotations = e.Annotations()
+		node.Children = []*Node{tree(e.cause, seen)}
+		for _, inner := range e.Suppressed() {
+			node.Suppressed = append(node.Suppressed, tree(inner, seen))
+		}
+	case interface{ Unwrap() []error }:
+		node.Kind, node.Message = NodeJoin, err.Error()
+		for _, inner := range e.Unwrap() {
+			if child := tree(inner, seen); child != nil {
+				node.Children = append(node.Children, child)
+			}
+		}
+	case interface{ Unwrap() error }:
+		node.Kind, node.Message = NodeWrap, err.Error()
+		if child := tree(e.Unwrap(), seen); child != nil {
+			node.Children = []*Node{child}
+		} else {
+			node.Kind = NodeCause
+		}
+	default:
+		node.Kind, node.Message = NodeCause, err.Error()
+	}
+	return node
+}
@@ -0,0 +1,51 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe returns the tree as one line per node, indented by depth.
+func describe(n *Node, indent string, sb *strings.Builder) {
+	fmt.Fprintf(sb, "%s%s %s %d\n", indent, n.Kind, n.Message, len(n.Annotations))
+	for _, child := range n.Children {
+		describe(child, indent+"  ", sb)
+	}
+	for _, child := range n.Suppressed {
+		describe(child, indent+"  ~", sb)
+	}
+}
+
+func TestTree(t *testing.T) {
+	if Tree(nil) != nil {
+		t.Errorf("expected nil")
+	}
+	box := NewBox()
+	box.PushIf(fmt.Errorf("reading: %w", errors.New("eof")), "loading")
+	box.Group("files").PushIf(errors.Join(errors.New("a"), errors.New("b")), "")
+	se := WithStack(errors.New("write"))
+	se.AddSuppressed(errors.New("close"))
+	box.PushIf(se, "")
+
+	var sb strings.Builder
+	describe(Tree(box), "", &sb)
+	want := `box  0
+  stack  1
+    wrap reading: eof 0
+      cause eof 0
+  group files 0
+    stack  1
+      join a
+b 0
+        cause a 0
+        cause b 0
+  stack  1
+    cause write 0
+    ~cause close 0
+`
+	if got := sb.String(); got != want {
+		t.Errorf("unexpected tree:\n%s\nexpected:\n%s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package errbox
+
+import "sync"
+
+// Validate runs all the checks, and returns a Box with all their failures, or nil, if all of them passed.
+// Unlike Run and Then, it does not stop at the first failure, so that the user can fix everything at once:
+//
+//	return errbox.Validate(
+//		func() error { return checkName(req.Name) },
+//		func() error { return checkEmail(req.Email) },
+//	)
+//
+// Failures are annotated with the place where Validate was called. Use Validator for named rules.
+func Validate(checks ...func() error) error {
+	box := NewBox()
+	for _, check := range checks {
+		box.pushErr(annotateErr(3, check(), ""))
+	}
+	if box.IsEmpty() {
+		return nil
+	}
+	return box
+}
+
+// Validator is a set of named validation rules. The zero value is ready to use:
+//
+//	var v errbox.Validator
+//	v.Rule("name is required", func() error { return required(req.Name) })
+//	v.Rule("email is valid", func() error { return checkEmail(req.Email) })
+//	if err := v.Validate(); err != nil {
+//		return err
+//	}
+//
+// A Validator can be prepared once, and used by more goroutines at the same time.
+type Validator struct {
+	mu    sync.Mutex
+	rules []validationRule
+}
+
+// validationRule is a rule of Validator.
+type validationRule struct {
+	name  string
+	check func() error
+}
+
+// Rule adds the rule with the name, and returns the validator back, so that calls can be chained.
+func (v *Validator) Rule(name string, check func() error) *Validator {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules = append(v.rules, validationRule{name: name, check: check})
+	return v
+}
+
+// Validate runs all rules in order, and returns a Box with all their failures, each of them annotated with
+// the name of the rule and the place where Validate was called, or nil, if all rules passed.
+func (v *Validator) Validate() error {
+	v.mu.Lock()
+	rules := append([]validationRule(nil), v.rules...)
+	v.mu.Unlock()
+
+	box := NewBox()
+	for _, rule := range rules {
+		box.pushErr(annotateErr(3, rule.check(), "rule %q failed", rule.name))
+	}
+	if box.IsEmpty() {
+		return nil
+	}
+	return box
+}
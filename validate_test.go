@@ -0,0 +1,44 @@
+package errbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	errName, errEmail := errors.New("name is empty"), errors.New("email is invalid")
+	err := Validate(
+		func() error { return errName },
+		func() error { return nil },
+		func() error { return errEmail },
+	)
+	if errs := Errors(err); len(errs) != 2 || !errors.Is(errs[0], errName) || !errors.Is(errs[1], errEmail) {
+		t.Errorf("expected both failures, got:\n%v", err)
+	}
+	if WithStack(Errors(err)[0]).annotation[0].function != "TestValidate" {
+		t.Errorf("expected the place where Validate was called")
+	}
+	if err := Validate(func() error { return nil }); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	var v Validator
+	v.Rule("name is required", func() error { return errors.New("empty") }).
+		Rule("age is positive", func() error { return nil }).
+		Rule("email is valid", func() error { return errors.New("missing @") })
+
+	err := v.Validate()
+	errs := Errors(err)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 failures, got:\n%v", err)
+	}
+	if !strings.Contains(errs[0].Error(), `rule "name is required" failed`) || !strings.Contains(errs[1].Error(), `rule "email is valid" failed`) {
+		t.Errorf("expected failures annotated with rule names, got:\n%v", err)
+	}
+	if err := new(Validator).Validate(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
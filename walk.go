@@ -0,0 +1,134 @@
+package errbox
+
+import "errors"
+
+// Walk visits every error in the tree of the err, depth first, in order: the err itself, errors it wraps
+// (including errors joined by errors.Join), suppressed errors (see StackErr.AddSuppressed), and errors stored in boxes. If the fn returns false, the walk stops.
+//
+// Walk enables generic tooling (metrics, redaction, export) without knowing errbox internals.
+//
+// Every Box is visited at most once, so that the walk terminates even if a box contains itself.
+func Walk(err error, fn func(err error) bool) {
+	var seen []*Box
+	walk(err, fn, &seen)
+}
+
+// walk implements Walk, returns false if the walk was stopped. The seen contains boxes visited so far.
+func walk(err error, fn func(err error) bool, seen *[]*Box) bool {
+	if isNil(err) {
+		return true
+	}
+	if b, ok := err.(*Box); ok {
+		for _, s := range *seen {
+			if s == b {
+				return true
+			}
+		}
+		*seen = append(*seen, b)
+	}
+	if !fn(err) {
+		return false
+	}
+	switch e := err.(type) {
+	case *StackErr:
+		if !walk(e.cause, fn, seen) {
+			return false
+		}
+		for _, inner := range e.Suppressed() {
+			if !walk(inner, fn, seen) {
+				return false
+			}
+		}
+	case *Box:
+		for _, inner := range Errors(e) {
+			if !walk(inner, fn, seen) {
+				return false
+			}
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if !walk(inner, fn, seen) {
+				return false
+			}
+		}
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), fn, seen)
+	}
+	return true
+}
+
+// Find looks for the first error of the type T in the err, all errors it wraps (including errors joined by
+// errors.Join), and all errors in boxes found along the way, in order (see Walk). It replaces loops over Errors
+// combined with errors.As:
+//
+//	if pathErr, ok := errbox.Find[*fs.PathError](err); ok {
+//		log.Println("failed path:", pathErr.Path)
+//	}
+func Find[T error](err error) (T, bool) {
+	var (
+		found T
+		ok    bool
+	)
+	Walk(err, func(e error) bool {
+		found, ok = e.(T)
+		return !ok
+	})
+	return found, ok
+}
+
+// lookup returns the first value found by the fn on the err, on all errors it wraps (see errors.Unwrap),
+// and on all errors stored in boxes found along the way. The outermost error wins; in a box, the first error wins.
+func lookup[T any](err error, fn func(se *StackErr) (T, bool)) (T, bool) {
+	var zero T
+	for ; !isNil(err); err = errors.Unwrap(err) {
+		if sb, ok := err.(*ShardedBox); ok {
+			err = sb.Box()
+		}
+		if b, ok := err.(*Box); ok {
+			for _, e := range Errors(b) {
+				if v, ok := lookup(e, fn); ok {
+					return v, true
+				}
+			}
+			return zero, false
+		}
+		if se, ok := err.(*StackErr); ok {
+			if v, ok := fn(se); ok {
+				return v, true
+			}
+		}
+	}
+	return zero, false
+}
+
+// lookupOuter works like lookup, but it does not look into boxes: only the err, and errors it wraps up to the first
+// box, are considered. Aggregators (like ExitCode) use it for marks set on errors which wrap a box.
+func lookupOuter[T any](err error, fn func(se *StackErr) (T, bool)) (T, bool) {
+	var zero T
+	for ; !isNil(err); err = errors.Unwrap(err) {
+		switch err.(type) {
+		case *Box, *ShardedBox:
+			return zero, false
+		}
+		if se, ok := err.(*StackErr); ok {
+			if v, ok := fn(se); ok {
+				return v, true
+			}
+		}
+	}
+	return zero, false
+}
+
+// boxIn returns the box which the err is, or which the err wraps (see errors.Unwrap), like a group of a box
+// (see Box.Group), or a box wrapped by fmt.Errorf with %w. Nil is returned if there is no such box.
+func boxIn(err error) *Box {
+	for ; !isNil(err); err = errors.Unwrap(err) {
+		switch b := err.(type) {
+		case *Box:
+			return b
+		case *ShardedBox:
+			return b.Box()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package errbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "data.csv", Err: fs.ErrNotExist}
+	box := Append(fmt.Errorf("unrelated"), Annotate(fmt.Errorf("loading: %w", pathErr), ""))
+	err := Annotate(errors.Join(errors.New("first"), box), "top")
+
+	if found, ok := Find[*fs.PathError](err); !ok || found.Path != "data.csv" {
+		t.Errorf("expected to find the path error, got %v, %v", found, ok)
+	}
+	if _, ok := Find[*fs.PathError](fmt.Errorf("nothing")); ok {
+		t.Errorf("expected not to find the path error")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	ShowStack(false)
+	defer ShowStack(true)
+
+	box := Append(errors.New("a"), errors.Join(errors.New("b"), errors.New("c")))
+	var visited []string
+	Walk(Annotate(fmt.Errorf("top: %w", box), ""), func(err error) bool {
+		if _, ok := err.(*StackErr); !ok {
+			visited = append(visited, err.Error())
+		}
+		return true
+	})
+	want := []string{"a", "b\nc", "b", "c"}
+	if fmt.Sprint(visited[2:]) != fmt.Sprint(want) {
+		t.Errorf("unexpected walk order: %q", visited)
+	}
+
+	n := 0
+	Walk(box, func(err error) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Errorf("expected the walk to stop after 2 errors, got %d", n)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	a, b := NewBox(), NewBox()
+	// bypass the guards against cycles
+	a.errLis = append(a.errLis, WithStack(fmt.Errorf("a: %w", b)))
+	b.errLis = append(b.errLis, WithStack(fmt.Errorf("b: %w", a)))
+
+	var n int
+	Walk(a, func(err error) bool { n++; return true })
+	if n != 6 {
+		t.Errorf("expected every box to be visited once, got %d errors", n)
+	}
+}